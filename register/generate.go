@@ -30,10 +30,16 @@ func saveInfo(identifier ID, meta Meta, doc Document, idKey SignKey, msgKey Decr
 	//fmt.Println("******** msg key:", msgKey.Map())
 	facebook := SharedFacebook()
 	database := facebook.GetDatabase()
-	// id key
+	// id key, it won't change, so save it only once
+	// (recovering from mnemonic regenerates the same one)
 	identityKey, ok := idKey.(PrivateKey)
 	if ok && identityKey != nil {
-		if database.SavePrivateKey(identityKey, "M", identifier) == false {
+		if old, _ := database.GetPrivateKeyForVisaSignature(identifier).(PrivateKey); old != nil {
+			if !old.Equal(identityKey) {
+				fmt.Println("identity key not match:", identifier)
+				return false
+			}
+		} else if database.SavePrivateKey(identityKey, "M", identifier) == false {
 			return false
 		}
 	}
@@ -52,6 +58,10 @@ func saveInfo(identifier ID, meta Meta, doc Document, idKey SignKey, msgKey Decr
 	if facebook.SaveDocument(doc, identifier) == false {
 		return false
 	}
+	// local user
+	if identifier.IsUser() && !facebook.AddLocalUser(identifier) {
+		fmt.Println("local user not added (already exists?):", identifier)
+	}
 	// OK
 	return true
 }
@@ -69,7 +79,17 @@ func doGenerate(path string, args []string) bool {
 		// check account type
 		aType := strings.ToLower(args[0])
 		if aType == "user" {
-			info := GenerateUserInfo(name, avatar)
+			var info *UserInfo
+			if mnemonic := getOptionString(args, "--mnemonic"); mnemonic != "" {
+				passphrase := getOptionString(args, "--passphrase")
+				info = GenerateUserInfoWithMnemonic(mnemonic, passphrase, name, avatar)
+				if info == nil {
+					fmt.Println("mnemonic error, please check the words")
+					return false
+				}
+			} else {
+				info = GenerateUserInfo(name, avatar)
+			}
 			return saveInfo(info.ID, info.Meta, info.Visa, info.IdentityKey, info.CommunicationKey)
 		} else if aType == "group" {
 			founder := ParseID(getOptionString(args, "--founder"))
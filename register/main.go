@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+
+	. "github.com/dimpart/demo-go/sdk/database"
 )
 
 func trimQuotations(text string) string {
@@ -56,7 +58,17 @@ func showHelp(path string) {
 		"\n    Commands:"+
 		"\n        generate                Generate account."+
 		"\n        modify                  Modify account info."+
+		"\n        mnemonic                Generate mnemonic words for backup."+
+		"\n        recover                 Recover user account from mnemonic words."+
+		"\n        list                    List local users and stored entities."+
+		"\n        show <ID>               Show meta, documents & keys of entity."+
+		"\n        export <ID>             Export meta & documents of entity as JSON."+
 		"\n        help                    Show help for commands."+
+		"\n"+
+		"\n    Storage Options:"+
+		"\n        --storage <backend>     Storage backend: 'file' (default), 'kv' or 'memory'."+
+		"\n        --root <dir>            Root directory for database, default is '/var/dim'."+
+		"\n        --password <passphrase> Passphrase for encrypting private keys."+
 		"\n\n", path)
 }
 
@@ -74,6 +86,8 @@ func doHelp(path string, args []string) {
 				"\n    Generate Options:"+
 				"\n        --seed <username>       Generate meta with seed string."+
 				"\n        --founder <ID>          Generate group meta with founder ID."+
+				"\n        --mnemonic <words>      Generate user with identity key derived from mnemonic words."+
+				"\n        --passphrase <text>     Optional passphrase for the mnemonic words."+
 				"\n\n", path)
 			return
 		} else if cmd == "modify" {
@@ -92,6 +106,67 @@ func doHelp(path string, args []string) {
 				"\n        --owner <ID>            Change group info with owner ID."+
 				"\n\n", path)
 			return
+		} else if cmd == "mnemonic" {
+			fmt.Printf("\n"+
+				"\n    Usages:"+
+				"\n        %s mnemonic [options]"+
+				"\n"+
+				"\n    Description:"+
+				"\n        Generate mnemonic words (BIP-39) for user account backup."+
+				"\n"+
+				"\n    Mnemonic Options:"+
+				"\n        --words <count>         Number of words: 12 (default), 15, 18, 21 or 24."+
+				"\n\n", path)
+			return
+		} else if cmd == "recover" {
+			fmt.Printf("\n"+
+				"\n    Usages:"+
+				"\n        %s recover --mnemonic <words> [options]"+
+				"\n"+
+				"\n    Description:"+
+				"\n        Recover user account (ID, meta & identity key) from mnemonic words,"+
+				"\n        a new visa key will be generated."+
+				"\n"+
+				"\n    Recover Options:"+
+				"\n        --mnemonic <words>      Mnemonic words, quoted."+
+				"\n        --passphrase <text>     Optional passphrase for the mnemonic words."+
+				"\n        --name <name>           Nickname for user."+
+				"\n        --avatar <URL>          Avatar URL for user."+
+				"\n\n", path)
+			return
+		} else if cmd == "list" {
+			fmt.Printf("\n"+
+				"\n    Usages:"+
+				"\n        %s list"+
+				"\n"+
+				"\n    Description:"+
+				"\n        List local users, and all entities stored under the storage root,"+
+				"\n        with type and name."+
+				"\n\n", path)
+			return
+		} else if cmd == "show" {
+			fmt.Printf("\n"+
+				"\n    Usages:"+
+				"\n        %s show <ID>"+
+				"\n"+
+				"\n    Description:"+
+				"\n        Show meta, documents, fingerprint, key algorithms"+
+				"\n        and anonymous name of the entity."+
+				"\n\n", path)
+			return
+		} else if cmd == "export" {
+			fmt.Printf("\n"+
+				"\n    Usages:"+
+				"\n        %s export <ID> [options]"+
+				"\n"+
+				"\n    Description:"+
+				"\n        Export meta & documents of the entity as JSON,"+
+				"\n        in the same fields as 'documents' command for other nodes to import."+
+				"\n"+
+				"\n    Export Options:"+
+				"\n        --output <path>         Write to file instead of stdout."+
+				"\n\n", path)
+			return
 		}
 	}
 	fmt.Printf("\n"+
@@ -104,12 +179,38 @@ func doHelp(path string, args []string) {
 		"\n    Commands:"+
 		"\n        generate"+
 		"\n        modify"+
+		"\n        mnemonic"+
+		"\n        recover"+
+		"\n        list"+
+		"\n        show"+
+		"\n        export"+
 		"\n\n", path)
 }
 
+func parseStorageOptions(args []string) bool {
+	if backend := getOptionString(args, "--storage"); backend != "" {
+		if !IsBackendSupported(backend) {
+			println("unknown storage backend: " + backend)
+			return false
+		}
+		storageConfig.Backend = backend
+	}
+	if root := getOptionString(args, "--root"); root != "" {
+		storageConfig.Root = root
+	}
+	if password := getOptionString(args, "--password"); password != "" {
+		storageConfig.Password = password
+	}
+	return true
+}
+
 func main() {
 	path := os.Args[0]
 	if len(os.Args) > 1 {
+		if !parseStorageOptions(os.Args[2:]) {
+			showHelp(path)
+			return
+		}
 		cmd := os.Args[1]
 		if cmd == "generate" {
 			doGenerate(path, os.Args[2:])
@@ -117,6 +218,21 @@ func main() {
 		} else if cmd == "modify" {
 			doModify(path, os.Args[2:])
 			return
+		} else if cmd == "mnemonic" {
+			doMnemonic(path, os.Args[2:])
+			return
+		} else if cmd == "recover" {
+			doRecover(path, os.Args[2:])
+			return
+		} else if cmd == "list" {
+			doList(path, os.Args[2:])
+			return
+		} else if cmd == "show" {
+			doShow(path, os.Args[2:])
+			return
+		} else if cmd == "export" {
+			doExport(path, os.Args[2:])
+			return
 		} else if cmd == "help" {
 			doHelp(path, os.Args[2:])
 			return
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/extensions"
+)
+
+func doMnemonic(path string, args []string) bool {
+	strength := MnemonicStrength
+	if opt := getOptionString(args, "--words"); opt != "" {
+		// 12, 15, 18, 21, 24
+		words := getOptionInteger(args, "--words")
+		if words < 12 || words > 24 || words%3 != 0 {
+			fmt.Println("number of words error:", opt)
+			doHelp(path, []string{"mnemonic"})
+			return false
+		}
+		strength = words / 3 * 32
+	}
+	mnemonic := GenerateMnemonic(strength)
+	if mnemonic == "" || !CheckMnemonic(mnemonic) {
+		doHelp(path, []string{"mnemonic"})
+		return false
+	}
+	fmt.Println("******** mnemonic:", mnemonic)
+	fmt.Println("Write down these words in order and keep them safe,")
+	fmt.Println("they are the only way to recover your account.")
+	return true
+}
+
+func doRecover(path string, args []string) bool {
+	mnemonic := getOptionString(args, "--mnemonic")
+	if mnemonic == "" {
+		doHelp(path, []string{"recover"})
+		return false
+	}
+	passphrase := getOptionString(args, "--passphrase")
+	name := getOptionString(args, "--name")
+	var avatar TransportableFile
+	url := getOptionString(args, "--avatar")
+	if url != "" {
+		avatar = CreateTransportableFile(nil, "", ParseURL(url), nil)
+	}
+	info := GenerateUserInfoWithMnemonic(mnemonic, passphrase, name, avatar)
+	if info == nil {
+		fmt.Println("mnemonic error, please check the words")
+		return false
+	}
+	// keep the old name if not changed
+	if name == "" {
+		facebook := SharedFacebook()
+		if old := facebook.GetVisa(info.ID); old != nil {
+			visa := info.Visa.(Visa)
+			visa.SetName(old.Name())
+			if avatar == nil && old.Avatar() != nil {
+				visa.SetAvatar(old.Avatar())
+			}
+			visa.Sign(info.IdentityKey)
+		}
+	}
+	return saveInfo(info.ID, info.Meta, info.Visa, info.IdentityKey, info.CommunicationKey)
+}
@@ -3,24 +3,133 @@ package main
 import (
 	"fmt"
 
+	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/mkm-go/protocol"
-	. "github.com/dimchat/mkm-go/types"
 )
 
-func showUser(did ID) bool {
-	info := getUserInfo(did)
-	if info == nil {
-		println("user not found: " + did.String())
+func loadDocument(did ID) Document {
+	facebook := SharedFacebook()
+	if did.IsGroup() {
+		return facebook.GetDocument(did, BULLETIN)
+	}
+	return facebook.GetDocument(did, VISA)
+}
+
+func showDocument(did ID, doc Document) {
+	name := doc.GetProperty("name")
+	if did.IsGroup() {
+		founder := doc.GetProperty("founder")
+		owner := doc.GetProperty("owner")
+		println(fmt.Sprintf("name: \"%v\", founder: %v, owner: %v", name, founder, owner))
+	} else if did.Type() == STATION {
+		host := doc.GetProperty("host")
+		port := doc.GetProperty("port")
+		println(fmt.Sprintf("name: \"%v\", host: %v, port: %v", name, host, port))
+	} else {
+		avatar := doc.GetProperty("avatar")
+		println(fmt.Sprintf("name: \"%v\", avatar: %v", name, avatar))
+	}
+}
+
+// apply changed properties from options
+//
+// Returns: false if nothing changed or option not fit for the entity
+func updateDocument(did ID, doc Document, args []string) bool {
+	changed := false
+	// name
+	if name := getOptionString(args, "--name"); name != "" {
+		switch info := doc.(type) {
+		case Visa:
+			info.SetName(name)
+		case Bulletin:
+			info.SetName(name)
+		default:
+			doc.SetProperty("name", name)
+		}
+		changed = true
+	}
+	// avatar
+	if url := getOptionString(args, "--avatar"); url != "" {
+		visa, ok := doc.(Visa)
+		if !ok {
+			println("avatar is only for user: " + did.String())
+			return false
+		}
+		visa.SetAvatar(ParseTransportableFile(url))
+		changed = true
+	}
+	// host & port
+	host := getOptionString(args, "--host")
+	port := 0
+	if opt := getOptionString(args, "--port"); opt != "" {
+		port = getOptionInteger(args, "--port")
+		if port <= 0 || port > 65535 {
+			println("port error: " + opt)
+			return false
+		}
+	}
+	if host != "" || port > 0 {
+		if did.Type() != STATION {
+			println("host & port are only for station: " + did.String())
+			return false
+		}
+		if host != "" {
+			doc.SetProperty("host", host)
+		}
+		if port > 0 {
+			doc.SetProperty("port", uint16(port))
+		}
+		changed = true
+	}
+	// owner
+	if text := getOptionString(args, "--owner"); text != "" {
+		owner := ParseID(text)
+		if owner == nil || !did.IsGroup() {
+			println("owner is only for group: " + did.String())
+			return false
+		}
+		doc.SetProperty("owner", owner.String())
+		changed = true
+	}
+	if !changed {
+		println("nothing changed: " + did.String())
+	}
+	return changed
+}
+
+func modifyDocument(did ID, args []string) bool {
+	facebook := SharedFacebook()
+	old := loadDocument(did)
+	if old == nil {
+		println("document not found: " + did.String())
+		return false
+	}
+	showDocument(did, old)
+	// modify a copy, keep the cached document unchanged on failure
+	doc := ParseDocument(old.CopyMap(false))
+	if doc == nil || !updateDocument(did, doc, args) {
+		return false
+	}
+	// group bulletin is signed by the founder
+	signer := did
+	if bulletin, ok := doc.(Bulletin); ok && bulletin.Founder() != nil {
+		signer = bulletin.Founder()
+	}
+	key := facebook.GetPrivateKeyForVisaSignature(signer)
+	if key == nil {
+		println("private key not found: " + signer.String())
 		return false
 	}
-	visa := info.Visa
-	if visa == nil {
-		println("visa not found: " + did.String())
+	if doc.Sign(key) == nil {
+		println("failed to sign document: " + did.String())
 		return false
 	}
-	name := visa.GetProperty("name")
-	avatar := visa.GetProperty("avatar")
-	println(fmt.Sprintf("name: \"%s\", avatar: %s", name, avatar))
+	if !facebook.SaveDocument(doc, did) {
+		println("failed to save document: " + did.String())
+		return false
+	}
+	println("document updated: " + did.String())
+	showDocument(did, doc)
 	return true
 }
 
@@ -28,17 +137,8 @@ func doModify(path string, args []string) bool {
 	if len(args) > 0 {
 		did := ParseID(args[0])
 		if did != nil {
-			showUser(did)
-		}
-		// arguments
-		name := getOptionString(args, "--name")
-		var avatar TransportableFile
-		url := getOptionString(args, "--avatar")
-		if url != "" {
-			avatar = CreateTransportableFile(nil, "", ParseURL(url), nil)
+			return modifyDocument(did, args[1:])
 		}
-		println("modify: ", name, avatar)
-		return true
 	}
 	doHelp(path, []string{"modify"})
 	return false
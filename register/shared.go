@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	. "github.com/dimpart/demo-go/sdk/client"
 	. "github.com/dimpart/demo-go/sdk/client/ext"
 	. "github.com/dimpart/demo-go/sdk/common"
@@ -10,7 +12,15 @@ import (
 
 var clientFacebook IClientFacebook = nil
 
+// storage backend, set by options "--storage" & "--root"
+var storageConfig = &StorageConfig{
+	Backend: BackendFile,
+	Root:    "/var/dim",
+}
+
 func createEntityChecker(adb AccountDBI) IEntityChecker {
+	// offline tool, no messenger to send queries & responses;
+	// clients get the real emitter installed by NewClientMessenger
 	emitter := &CheckEmitter{}
 	checker := NewEntityChecker(adb)
 	checker.Request = emitter
@@ -20,7 +30,16 @@ func createEntityChecker(adb AccountDBI) IEntityChecker {
 
 func createFacebook() IClientFacebook {
 	// create database for facebook
-	database := NewStorage("/var/dim")
+	database := NewStorageWithConfig(storageConfig)
+	if database == nil {
+		println("failed to open storage: " + storageConfig.Backend)
+		os.Exit(1)
+	}
+	// the root is final after options parsed, upgrade the storage layout
+	if !database.Migrate() {
+		println("failed to upgrade storage layout")
+		os.Exit(1)
+	}
 	facebook := NewClientFacebook(database)
 	// set archivist & barrack
 	archivist := NewCommonArchivist(facebook, database)
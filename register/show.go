@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/mkm"
+	. "github.com/dimpart/demo-go/sdk/extensions"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+func entityTypeName(did ID) string {
+	switch did.Type() {
+	case USER:
+		return "USER"
+	case GROUP:
+		return "GROUP"
+	case STATION:
+		return "STATION"
+	case ISP:
+		return "ISP"
+	case BOT:
+		return "BOT"
+	case ICP:
+		return "ICP"
+	}
+	return fmt.Sprintf("0x%02x", uint8(did.Type()))
+}
+
+func doList(path string, args []string) bool {
+	facebook := SharedFacebook()
+	database := facebook.GetDatabase()
+	// local users
+	users := facebook.GetLocalUsers()
+	fmt.Println("******** local users:", len(users))
+	for _, did := range users {
+		fmt.Printf("  %-8s %s \"%s\"\n", entityTypeName(did), did, facebook.GetName(did))
+	}
+	// stored entities
+	entityDB, ok := database.(EntityDBI)
+	if !ok {
+		fmt.Println("storage not support listing entities")
+		return false
+	}
+	// local users listed above, skip them
+	entities := make([]ID, 0, 16)
+	for _, did := range entityDB.GetEntities() {
+		if !containsID(users, did) {
+			entities = append(entities, did)
+		}
+	}
+	fmt.Println("******** other entities:", len(entities))
+	for _, did := range entities {
+		fmt.Printf("  %-8s %s \"%s\"\n", entityTypeName(did), did, facebook.GetName(did))
+	}
+	return true
+}
+
+func containsID(array []ID, did ID) bool {
+	for _, item := range array {
+		if item.Equal(did) {
+			return true
+		}
+	}
+	return false
+}
+
+func doShow(path string, args []string) bool {
+	var did ID
+	if len(args) > 0 {
+		did = ParseID(args[0])
+	}
+	if did == nil {
+		doHelp(path, []string{"show"})
+		return false
+	}
+	facebook := SharedFacebook()
+	meta := facebook.GetMeta(did)
+	if meta == nil {
+		fmt.Println("meta not found: " + did.String())
+		return false
+	}
+	fmt.Println("******** ID:", did)
+	fmt.Println("    type:", entityTypeName(did))
+	fmt.Println("    anonymous name:", AnonymousGetName(did))
+	// meta
+	fmt.Println("******** meta:", JSONEncodeMap(meta.Map()))
+	fmt.Println("    type:", meta.Type())
+	fmt.Println("    key algorithm:", meta.PublicKey().Algorithm())
+	if seed := meta.Seed(); seed != "" {
+		fmt.Println("    seed:", seed)
+	}
+	if fingerprint := meta.Fingerprint(); fingerprint != nil {
+		fmt.Println("    fingerprint:", fingerprint.Serialize())
+	}
+	// documents
+	documents := facebook.GetDocuments(did)
+	fmt.Println("******** documents:", len(documents))
+	for _, doc := range documents {
+		fmt.Println("    ", JSONEncodeMap(doc.Map()))
+		fmt.Printf("      type: %s, time: %v, verified: %v\n",
+			GetDocumentType(doc), doc.Time(), doc.Verify(meta.PublicKey()))
+	}
+	if visa := GetLastVisa(documents); visa != nil && visa.PublicKey() != nil {
+		fmt.Println("    visa key algorithm:", visa.PublicKey().Algorithm())
+	}
+	// private keys
+	if idKey := facebook.GetPrivateKeyForVisaSignature(did); idKey != nil {
+		fmt.Println("******** private keys:")
+		fmt.Println("    identity key algorithm:", idKey.Algorithm())
+		for _, key := range facebook.GetPrivateKeysForDecryption(did) {
+			fmt.Println("    decryption key algorithm:", key.Algorithm())
+		}
+	}
+	return true
+}
+
+/**
+ *  Exported entity, same fields as the 'documents' command,
+ *  so other nodes can import it like a response from the network
+ *
+ *  format: {
+ *      "did"       : "{ID}",
+ *      "meta"      : {...},
+ *      "documents" : [...]
+ *  }
+ */
+func doExport(path string, args []string) bool {
+	var did ID
+	if len(args) > 0 {
+		did = ParseID(args[0])
+	}
+	if did == nil {
+		doHelp(path, []string{"export"})
+		return false
+	}
+	output := getOptionString(args, "--output")
+	if output == "" {
+		// keep stdout clean for the JSON
+		LogLevel = 0
+	}
+	facebook := SharedFacebook()
+	meta := facebook.GetMeta(did)
+	if meta == nil {
+		println("meta not found: " + did.String())
+		return false
+	}
+	info := NewMap()
+	info["did"] = did.String()
+	info["meta"] = meta.Map()
+	info["documents"] = DocumentRevert(facebook.GetDocuments(did))
+	json := JSONEncodeMap(info)
+	if output != "" {
+		if !WriteTextFile(output, json) {
+			println("failed to write file: " + output)
+			return false
+		}
+		println("exported to: " + output)
+		return true
+	}
+	fmt.Println(json)
+	return true
+}
@@ -0,0 +1,128 @@
+package sdk
+
+import (
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/mkm"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// ClientCheckEmitter sends the entity queries & responses via the messenger
+//
+// Rate limiting is done by the EntityChecker before calling this emitter
+type ClientCheckEmitter struct {
+	//ICheckEmitter
+
+	Messenger ICommonMessenger
+}
+
+func NewClientCheckEmitter(messenger ICommonMessenger) *ClientCheckEmitter {
+	return &ClientCheckEmitter{
+		Messenger: messenger,
+	}
+}
+
+// Override
+func (emitter *ClientCheckEmitter) QueryMeta(did ID) bool {
+	cmd := NewCommandForQueryMeta(did)
+	station := ParseID(AnyStation)
+	return emitter.sendContent(cmd, station)
+}
+
+// Override
+func (emitter *ClientCheckEmitter) QueryDocuments(did ID, docs []Document) bool {
+	cmd := NewCommandForQueryDocuments(did, getLastDocumentTime(docs))
+	station := ParseID(AnyStation)
+	return emitter.sendContent(cmd, station)
+}
+
+// Override
+func (emitter *ClientCheckEmitter) QueryMembers(gid ID, members []ID) bool {
+	messenger := emitter.Messenger
+	if messenger == nil {
+		//panic("messenger not ready")
+		return false
+	}
+	facebook := messenger.GetFacebook()
+	//
+	//  ask the last active member first,
+	//  or the group owner
+	//
+	checker := facebook.GetEntityChecker()
+	receiver := checker.GetLastActiveMember(gid)
+	if receiver == nil {
+		receiver = facebook.GetOwner(gid)
+	}
+	if receiver == nil {
+		//panic("group owner not found")
+		return false
+	}
+	content := NewCustomizedContent("chat.dim.group", "history", "query")
+	content.SetGroup(gid)
+	if his, ok := checker.(interface{ GetLastGroupHistoryTime(ID) Time }); ok {
+		if lastTime := his.GetLastGroupHistoryTime(gid); !TimeIsNil(lastTime) {
+			content.Set("last_time", TimeToFloat64(lastTime))
+		}
+	}
+	return emitter.sendContent(content, receiver)
+}
+
+// Override
+func (emitter *ClientCheckEmitter) SendVisa(visa Visa, receiver ID, updated bool) bool {
+	messenger := emitter.Messenger
+	if messenger == nil {
+		//panic("messenger not ready")
+		return false
+	}
+	me := GetDocumentID(visa)
+	if me == nil {
+		//panic("visa ID not found")
+		return false
+	} else if receiver.Equal(me) {
+		// no need to send visa to myself
+		return false
+	}
+	cmd := RespondDocument(me, nil, visa)
+	if cmd == nil {
+		//panic("failed to create document command")
+		return false
+	}
+	pair := messenger.SendContent(cmd, me, receiver, 1)
+	return pair.Second() != nil
+}
+
+// private
+func (emitter *ClientCheckEmitter) sendContent(content Content, receiver ID) bool {
+	messenger := emitter.Messenger
+	if messenger == nil {
+		//panic("messenger not ready")
+		return false
+	}
+	user := messenger.GetFacebook().GetCurrentUser()
+	if user == nil {
+		//panic("current user not found")
+		return false
+	}
+	pair := messenger.SendContent(content, user.ID(), receiver, 1)
+	return pair.Second() != nil
+}
+
+func getLastDocumentTime(documents []Document) Time {
+	var lastTime Time
+	var docTime Time
+	for _, doc := range documents {
+		docTime = doc.Time()
+		if TimeIsNil(docTime) {
+			//panic("document error")
+		} else if TimeIsNil(lastTime) || TimeIsBefore(docTime, lastTime) {
+			lastTime = docTime
+		}
+	}
+	return lastTime
+}
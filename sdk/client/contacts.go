@@ -0,0 +1,363 @@
+package sdk
+
+import (
+	"sync"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+)
+
+// ContactManagerDBI combines the tables needed by the contact manager
+type ContactManagerDBI interface {
+	ContactDBI
+	ContactInfoDBI
+	MuteDBI
+	BlockDBI
+}
+
+/**
+ *  Contact Manager
+ *  ~~~~~~~~~~~~~~~
+ *  Manage contacts of the current user,
+ *  mute-list & block-list will be synchronized to the station
+ */
+
+type ContactManager struct {
+	Facebook  ICommonFacebook
+	Messenger ICommonMessenger
+
+	Database ContactManagerDBI
+
+	// serializes read-modify-write of contact settings
+	infoLock sync.Mutex
+}
+
+func NewContactManager(facebook ICommonFacebook, messenger ICommonMessenger, db ContactManagerDBI) *ContactManager {
+	return &ContactManager{
+		Facebook:  facebook,
+		Messenger: messenger,
+		Database:  db,
+	}
+}
+
+// protected
+func (manager *ContactManager) GetCurrentUser() ID {
+	user := manager.Facebook.GetCurrentUser()
+	if user == nil {
+		//panic("current user not found")
+		return nil
+	}
+	return user.ID()
+}
+
+//
+//  Contacts
+//
+
+func (manager *ContactManager) GetContacts() []ID {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return nil
+	}
+	return manager.Database.GetContacts(me)
+}
+
+func (manager *ContactManager) AddContact(contact ID) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	contacts := manager.Database.GetContacts(me)
+	if containsID(contacts, contact) {
+		// duplicated
+		return false
+	}
+	array := make([]ID, 0, len(contacts)+1)
+	array = append(array, contacts...)
+	array = append(array, contact)
+	return manager.Database.SaveContacts(array, me)
+}
+
+func (manager *ContactManager) RemoveContact(contact ID) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	contacts := manager.Database.GetContacts(me)
+	array, ok := removeID(contacts, contact)
+	if !ok {
+		// contact not found
+		return false
+	}
+	return manager.Database.SaveContacts(array, me)
+}
+
+//
+//  Contact Settings
+//
+
+// GetContactInfo returns the settings of the contact (nil if not set)
+func (manager *ContactManager) GetContactInfo(contact ID) *ContactInfo {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return nil
+	}
+	infos := manager.Database.GetContactInfos(me)
+	for _, item := range infos {
+		if item.ID.Equal(contact) {
+			return item
+		}
+	}
+	return nil
+}
+
+// private
+func (manager *ContactManager) updateContactInfo(contact ID, update func(info *ContactInfo)) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	manager.infoLock.Lock()
+	defer manager.infoLock.Unlock()
+	// the cached entries may be read by others, so
+	// modify a copy and replace it in a new slice
+	infos := manager.Database.GetContactInfos(me)
+	array := make([]*ContactInfo, 0, len(infos)+1)
+	var info *ContactInfo
+	for _, item := range infos {
+		if info == nil && item.ID.Equal(contact) {
+			info = copyContactInfo(item)
+			array = append(array, info)
+		} else {
+			array = append(array, item)
+		}
+	}
+	if info == nil {
+		info = &ContactInfo{
+			ID:   contact,
+			Tags: make([]string, 0),
+		}
+		array = append(array, info)
+	}
+	update(info)
+	return manager.Database.SaveContactInfos(array, me)
+}
+
+func copyContactInfo(info *ContactInfo) *ContactInfo {
+	tags := make([]string, len(info.Tags))
+	copy(tags, info.Tags)
+	return &ContactInfo{
+		ID:     info.ID,
+		Remark: info.Remark,
+		Alias:  info.Alias,
+		Tags:   tags,
+	}
+}
+
+func (manager *ContactManager) SetRemark(contact ID, remark string) bool {
+	return manager.updateContactInfo(contact, func(info *ContactInfo) {
+		info.Remark = remark
+	})
+}
+
+func (manager *ContactManager) SetAlias(contact ID, alias string) bool {
+	return manager.updateContactInfo(contact, func(info *ContactInfo) {
+		info.Alias = alias
+	})
+}
+
+func (manager *ContactManager) AddTag(contact ID, tag string) bool {
+	return manager.updateContactInfo(contact, func(info *ContactInfo) {
+		for _, item := range info.Tags {
+			if item == tag {
+				return
+			}
+		}
+		info.Tags = append(info.Tags, tag)
+	})
+}
+
+func (manager *ContactManager) RemoveTag(contact ID, tag string) bool {
+	return manager.updateContactInfo(contact, func(info *ContactInfo) {
+		tags := make([]string, 0, len(info.Tags))
+		for _, item := range info.Tags {
+			if item != tag {
+				tags = append(tags, item)
+			}
+		}
+		info.Tags = tags
+	})
+}
+
+// GetContactsWithTag returns the contacts labeled with the tag
+func (manager *ContactManager) GetContactsWithTag(tag string) []ID {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return nil
+	}
+	infos := manager.Database.GetContactInfos(me)
+	array := make([]ID, 0, len(infos))
+	for _, info := range infos {
+		for _, item := range info.Tags {
+			if item == tag {
+				array = append(array, info.ID)
+				break
+			}
+		}
+	}
+	return array
+}
+
+// GetDisplayName returns the alias if set, else the name from the document
+func (manager *ContactManager) GetDisplayName(contact ID) string {
+	info := manager.GetContactInfo(contact)
+	if info != nil && info.Alias != "" {
+		return info.Alias
+	}
+	return manager.Facebook.GetName(contact)
+}
+
+//
+//  Mute-List
+//
+
+func (manager *ContactManager) GetMuteList() []ID {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return nil
+	}
+	return manager.Database.GetMuteList(me)
+}
+
+func (manager *ContactManager) IsMuted(contact ID) bool {
+	return containsID(manager.GetMuteList(), contact)
+}
+
+func (manager *ContactManager) Mute(contact ID) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	muted := manager.Database.GetMuteList(me)
+	if containsID(muted, contact) {
+		// duplicated
+		return false
+	}
+	array := make([]ID, 0, len(muted)+1)
+	array = append(array, muted...)
+	array = append(array, contact)
+	return manager.saveMuteList(array, me)
+}
+
+func (manager *ContactManager) Unmute(contact ID) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	muted := manager.Database.GetMuteList(me)
+	array, ok := removeID(muted, contact)
+	if !ok {
+		// not muted
+		return false
+	}
+	return manager.saveMuteList(array, me)
+}
+
+// private
+func (manager *ContactManager) saveMuteList(muted []ID, me ID) bool {
+	if !manager.Database.SaveMuteList(muted, me) {
+		return false
+	}
+	// sync to station
+	manager.sendToStation(NewMuteCommand(muted), me)
+	return true
+}
+
+//
+//  Block-List
+//
+
+func (manager *ContactManager) GetBlockList() []ID {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return nil
+	}
+	return manager.Database.GetBlockList(me)
+}
+
+func (manager *ContactManager) IsBlocked(contact ID) bool {
+	return containsID(manager.GetBlockList(), contact)
+}
+
+func (manager *ContactManager) Block(contact ID) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	blocked := manager.Database.GetBlockList(me)
+	if containsID(blocked, contact) {
+		// duplicated
+		return false
+	}
+	array := make([]ID, 0, len(blocked)+1)
+	array = append(array, blocked...)
+	array = append(array, contact)
+	return manager.saveBlockList(array, me)
+}
+
+func (manager *ContactManager) Unblock(contact ID) bool {
+	me := manager.GetCurrentUser()
+	if me == nil {
+		return false
+	}
+	blocked := manager.Database.GetBlockList(me)
+	array, ok := removeID(blocked, contact)
+	if !ok {
+		// not blocked
+		return false
+	}
+	return manager.saveBlockList(array, me)
+}
+
+// private
+func (manager *ContactManager) saveBlockList(blocked []ID, me ID) bool {
+	if !manager.Database.SaveBlockList(blocked, me) {
+		return false
+	}
+	// sync to station
+	manager.sendToStation(NewBlockCommand(blocked), me)
+	return true
+}
+
+// private
+func (manager *ContactManager) sendToStation(content Content, me ID) {
+	messenger := manager.Messenger
+	if messenger == nil {
+		// offline mode
+		return
+	}
+	station := ParseID(AnyStation)
+	messenger.SendContent(content, me, station, 1)
+}
+
+func containsID(array []ID, did ID) bool {
+	for _, item := range array {
+		if item.Equal(did) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeID(array []ID, did ID) ([]ID, bool) {
+	result := make([]ID, 0, len(array))
+	for _, item := range array {
+		if !item.Equal(did) {
+			result = append(result, item)
+		}
+	}
+	return result, len(result) < len(array)
+}
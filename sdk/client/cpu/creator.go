@@ -52,12 +52,20 @@ func (creator *ClientContentProcessorCreator) CreateContentProcessor(msgType Mes
 
 func (creator *ClientContentProcessorCreator) CreateCommandProcessor(msgType MessageType, cmdName string) ContentProcessor {
 	switch cmdName {
+	// meta & documents
+	case META:
+		return NewClientMetaCommandProcessor(creator.Facebook, creator.Messenger)
+	case DOCUMENTS:
+		return NewClientDocumentCommandProcessor(creator.Facebook, creator.Messenger)
+	// receipt
 	case RECEIPT:
 		return NewReceiptCommandProcessor(creator.Facebook, creator.Messenger)
 	case HANDSHAKE:
 		return NewHandshakeCommandProcessor(creator.Facebook, creator.Messenger)
 	case LOGIN:
 		return NewLoginCommandProcessor(creator.Facebook, creator.Messenger)
+	case PRESENCE:
+		return NewPresenceCommandProcessor(creator.Facebook, creator.Messenger)
 	}
 	// others
 	return creator.BaseContentProcessorCreator.CreateCommandProcessor(msgType, cmdName)
@@ -67,6 +75,18 @@ func (creator *ClientContentProcessorCreator) CreateCommandProcessor(msgType Mes
 //  Factories
 //
 
+func NewClientMetaCommandProcessor(facebook Facebook, messenger Messenger) ContentProcessor {
+	return &ClientMetaCommandProcessor{
+		MetaCommandProcessor: NewMetaCommandProcessor(facebook, messenger),
+	}
+}
+
+func NewClientDocumentCommandProcessor(facebook Facebook, messenger Messenger) ContentProcessor {
+	return &ClientDocumentCommandProcessor{
+		DocumentCommandProcessor: NewDocumentCommandProcessor(facebook, messenger),
+	}
+}
+
 func NewReceiptCommandProcessor(facebook Facebook, messenger Messenger) ContentProcessor {
 	return &ReceiptCommandProcessor{
 		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
@@ -85,6 +105,12 @@ func NewLoginCommandProcessor(facebook Facebook, messenger Messenger) ContentPro
 	}
 }
 
+func NewPresenceCommandProcessor(facebook Facebook, messenger Messenger) ContentProcessor {
+	return &PresenceCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+	}
+}
+
 func NewCustomizedContentProcessor(facebook Facebook, messenger Messenger) *CustomizedContentProcessor {
 	return &CustomizedContentProcessor{
 		BaseContentProcessor: NewBaseContentProcessor(facebook, messenger),
@@ -0,0 +1,75 @@
+package cpu
+
+import (
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/mkm"
+)
+
+/**
+ *  CPU for DocumentCommand
+ *  ~~~~~~~~~~~~~~~~~~~~~~~
+ *  Only answer queries for local users' visa,
+ *  received documents will be verified & saved by the archivist
+ */
+
+type ClientDocumentCommandProcessor struct {
+	*DocumentCommandProcessor
+}
+
+func (cpu *ClientDocumentCommandProcessor) GetFacebook() ICommonFacebook {
+	facebook := cpu.BaseCommandProcessor.Facebook
+	return facebook.(ICommonFacebook)
+}
+
+// Override
+func (cpu *ClientDocumentCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(DocumentCommand)
+	if !ok {
+		return nil
+	}
+	did := command.ID()
+	if did == nil || command.Documents() != nil {
+		// let the super processor save the documents (or respond error)
+		return cpu.DocumentCommandProcessor.ProcessContent(content, rMsg)
+	}
+	facebook := cpu.GetFacebook()
+	if !isLocalUser(facebook, did) {
+		// not my business
+		return nil
+	} else if rMsg.Sender().Equal(did) {
+		// cycled query
+		return nil
+	}
+	// query visa for local user
+	visa := facebook.GetVisa(did)
+	if visa == nil {
+		return cpu.RespondReceipt("Document not found.", rMsg.Envelope(), content, StringKeyMap{
+			"template": "Document not found: ${did}",
+			"replacements": StringKeyMap{
+				"did": did.String(),
+			},
+		})
+	}
+	queryTime := command.LastTime()
+	docTime := visa.Time()
+	if !TimeIsNil(queryTime) && !TimeIsNil(docTime) && TimeToInt64(docTime) <= TimeToInt64(queryTime) {
+		// document not updated
+		return cpu.RespondReceipt("Document not updated.", rMsg.Envelope(), content, StringKeyMap{
+			"template": "Document not updated: ${did}",
+			"replacements": StringKeyMap{
+				"did": did.String(),
+			},
+		})
+	}
+	meta := facebook.GetMeta(did)
+	res := RespondDocument(did, meta, visa)
+	if res == nil {
+		//panic("failed to respond visa: " + did.String())
+		return nil
+	}
+	return []Content{res}
+}
@@ -0,0 +1,60 @@
+package cpu
+
+import (
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common"
+)
+
+/**
+ *  CPU for MetaCommand
+ *  ~~~~~~~~~~~~~~~~~~~
+ *  Only answer queries for local users,
+ *  received meta will be saved by the archivist
+ */
+
+type ClientMetaCommandProcessor struct {
+	*MetaCommandProcessor
+}
+
+func (cpu *ClientMetaCommandProcessor) GetFacebook() ICommonFacebook {
+	facebook := cpu.BaseCommandProcessor.Facebook
+	return facebook.(ICommonFacebook)
+}
+
+// Override
+func (cpu *ClientMetaCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(MetaCommand)
+	if !ok {
+		return nil
+	}
+	did := command.ID()
+	if did == nil || command.Meta() != nil {
+		// let the super processor save the meta (or respond error)
+		return cpu.MetaCommandProcessor.ProcessContent(content, rMsg)
+	} else if !isLocalUser(cpu.GetFacebook(), did) {
+		// not my business
+		return nil
+	} else if rMsg.Sender().Equal(did) {
+		// cycled query
+		return nil
+	}
+	// query meta for local user
+	return cpu.MetaCommandProcessor.ProcessContent(content, rMsg)
+}
+
+// private
+func isLocalUser(facebook ICommonFacebook, did ID) bool {
+	if !did.IsUser() || did.IsBroadcast() {
+		return false
+	}
+	db := facebook.GetDatabase()
+	for _, item := range db.GetLocalUsers() {
+		if item.Equal(did) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+type PresenceCommandProcessor struct {
+	*BaseCommandProcessor
+}
+
+// Override
+func (cpu *PresenceCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(PresenceCommand)
+	if !ok {
+		return nil
+	}
+	did := command.ID()
+	if did == nil {
+		return nil
+	}
+	info := NewMap()
+	info["ID"] = did.String()
+	info["online"] = command.IsOnline()
+	lastSeen := command.LastSeen()
+	if !TimeIsNil(lastSeen) {
+		info["last_seen"] = TimeToFloat64(lastSeen)
+	}
+	// post notification: PRESENCE_UPDATED
+	NotificationPost("presence_updated", cpu, info)
+	// no need to respond presence command
+	return nil
+}
@@ -1,16 +1,90 @@
 package cpu
 
 import (
+	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
 	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimchat/sdk-go/sdk"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
+/**
+ *  CPU for ReceiptCommand
+ *  ~~~~~~~~~~~~~~~~~~~~~~
+ *  Match the receipt with the outbound message log,
+ *  receipt from station means 'sent', from the receiver means 'delivered'
+ */
+
 type ReceiptCommandProcessor struct {
 	*BaseCommandProcessor
 }
 
 // Override
 func (cpu *ReceiptCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(ReceiptCommand)
+	if !ok {
+		return nil
+	}
+	env := command.OriginalEnvelope()
+	sn := command.OriginalSerialNumber()
+	if env == nil || sn == 0 {
+		// not a receipt for message
+		return nil
+	}
+	var state int
+	var name string
+	if rMsg.Sender().Type() == STATION {
+		state = MessageSent
+		name = "message_sent"
+	} else {
+		state = MessageDelivered
+		name = "message_delivered"
+	}
+	signature := GetSignatureDigest(command.OriginalSignature())
+	updateMessageState(cpu.Facebook, name, env.Sender(), env.Receiver(), sn, signature, state, cpu)
 	// no need to response receipt command
 	return nil
 }
+
+// updateMessageState updates the delivery state of the outgoing message,
+// and posts notification with info {"ID", "sn", "signature", "state"}
+func updateMessageState(facebook Facebook, name string, sender, receiver ID, sn SerialNumberType, signature string,
+	state int, poster interface{}) bool {
+	db := getOutbox(facebook)
+	if db == nil {
+		return false
+	}
+	msg := db.GetOutgoingMessage(sender, receiver, sn)
+	if msg == nil {
+		// not my message
+		return false
+	} else if signature != "" && msg.Signature != "" && msg.Signature != signature {
+		// signature not matched
+		return false
+	} else if !db.UpdateOutgoingMessageState(sender, receiver, sn, state) {
+		// state not changed
+		return false
+	} else if history, ok := db.(MessageHistoryDBI); ok {
+		history.UpdateMessageState(sender, receiver, sender, sn, state)
+	}
+	info := NewMap()
+	info["ID"] = receiver.String()
+	info["sn"] = sn
+	info["signature"] = msg.Signature
+	info["state"] = state
+	NotificationPost(name, poster, info)
+	return true
+}
+
+func getOutbox(facebook Facebook) OutboxDBI {
+	if cf, ok := facebook.(ICommonFacebook); ok {
+		db, _ := cf.GetDatabase().(OutboxDBI)
+		return db
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/sdk-go/sdk"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+/*
+Typing Indicator:
+
+	"app" : "chat.dim.status"
+	"mod" : "typing"
+	"act" : "start" / "stop"
+
+Post notification "typing_changed" with info {"ID", "group", "typing"}
+*/
+type TypingHandler struct {
+	BaseCustomizedHandler
+}
+
+// Override
+func (handler TypingHandler) HandleContent(content CustomizedContent, rMsg ReliableMessage, messenger Messenger) []Content {
+	act := content.Action()
+	if act != TypingStart && act != TypingStop {
+		return handler.BaseCustomizedHandler.HandleContent(content, rMsg, messenger)
+	}
+	info := NewMap()
+	info["ID"] = rMsg.Sender().String()
+	if group := content.Group(); group != nil {
+		info["group"] = group.String()
+	}
+	info["typing"] = act == TypingStart
+	// post notification: TYPING_CHANGED
+	NotificationPost("typing_changed", handler, info)
+	// no need to respond typing indicator
+	return nil
+}
+
+/*
+Message Receipt:
+
+	"app" : "chat.dim.status"
+	"mod" : "receipt"
+	"act" : "delivered" / "read"
+
+Update the state of the outgoing message if tracked,
+and post notification "message_delivered" / "message_read"
+*/
+type MessageReceiptHandler struct {
+	BaseCustomizedHandler
+}
+
+// Override
+func (handler MessageReceiptHandler) HandleContent(content CustomizedContent, rMsg ReliableMessage, messenger Messenger) []Content {
+	var name string
+	var state int
+	act := content.Action()
+	switch act {
+	case ReceiptDelivered:
+		name = "message_delivered"
+		state = MessageDelivered
+	case ReceiptRead:
+		name = "message_read"
+		state = MessageRead
+	default:
+		return handler.BaseCustomizedHandler.HandleContent(content, rMsg, messenger)
+	}
+	sn, signature := GetReceiptOrigin(content)
+	if sn == 0 && signature == "" {
+		return handler.RespondReceipt("Receipt error.", rMsg.Envelope(), content, nil)
+	}
+	// update the state of outgoing message
+	if transceiver, ok := messenger.(ICommonMessenger); ok {
+		receiver := content.Group()
+		if receiver == nil {
+			receiver = rMsg.Sender()
+		}
+		me := rMsg.Receiver()
+		if updateMessageState(transceiver.GetFacebook(), name, me, receiver, sn, signature, state, handler) {
+			// notification posted
+			return nil
+		}
+	}
+	info := NewMap()
+	info["ID"] = rMsg.Sender().String()
+	if group := content.Group(); group != nil {
+		info["group"] = group.String()
+	}
+	info["sn"] = sn
+	info["signature"] = signature
+	// post notification: MESSAGE_DELIVERED / MESSAGE_READ
+	NotificationPost(name, handler, info)
+	// no need to respond receipt
+	return nil
+}
+
+/*
+Presence Event:
+
+	"app" : "chat.dim.status"
+	"mod" : "presence"
+	"act" : "online" / "offline"
+
+Post notification "presence_updated" with info {"ID", "online", "last_seen"}
+*/
+type PresenceHandler struct {
+	BaseCustomizedHandler
+}
+
+// Override
+func (handler PresenceHandler) HandleContent(content CustomizedContent, rMsg ReliableMessage, messenger Messenger) []Content {
+	act := content.Action()
+	if act != PresenceOnline && act != PresenceOffline {
+		return handler.BaseCustomizedHandler.HandleContent(content, rMsg, messenger)
+	}
+	did := ParseID(content.Get("did"))
+	if did == nil {
+		return handler.RespondReceipt("Presence event error.", rMsg.Envelope(), content, nil)
+	}
+	info := NewMap()
+	info["ID"] = did.String()
+	info["online"] = act == PresenceOnline
+	lastSeen := content.GetTime("last_seen", nil)
+	if !TimeIsNil(lastSeen) {
+		info["last_seen"] = TimeToFloat64(lastSeen)
+	}
+	// post notification: PRESENCE_UPDATED
+	NotificationPost("presence_updated", handler, info)
+	// no need to respond presence event
+	return nil
+}
@@ -3,6 +3,7 @@ package ext
 import (
 	. "github.com/dimpart/demo-go/sdk/client/cpu"
 	. "github.com/dimpart/demo-go/sdk/common/ext"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
 )
 
 type ClientExtensionLoader struct {
@@ -24,5 +25,12 @@ func registerCustomizedHandlers() {
 	handler := &GroupHistoryHandler{}
 	filter.SetContentHandler("chat.dim.group", "history", handler)
 
+	// 'chat.dim.status:typing'
+	filter.SetContentHandler(StatusApp, TypingModule, &TypingHandler{})
+	// 'chat.dim.status:receipt'
+	filter.SetContentHandler(StatusApp, ReceiptModule, &MessageReceiptHandler{})
+	// 'chat.dim.status:presence'
+	filter.SetContentHandler(StatusApp, PresenceModule, &PresenceHandler{})
+
 	SetCustomizedContentFilter(filter)
 }
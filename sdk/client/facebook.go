@@ -26,6 +26,17 @@ func NewClientFacebook(db Database) *ClientFacebook {
 	}
 }
 
+// NewClientFacebookWithConfig creates facebook with the storage backend selected by config
+//
+// Returns: nil for unknown backend, or backend not opened
+func NewClientFacebookWithConfig(config *StorageConfig) *ClientFacebook {
+	db := NewStorageWithConfig(config)
+	if db == nil {
+		return nil
+	}
+	return NewClientFacebook(db)
+}
+
 //
 //  GroupDataSource
 //
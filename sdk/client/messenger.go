@@ -1,10 +1,14 @@
 package sdk
 
 import (
+	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/sdk-go/core"
 	. "github.com/dimpart/demo-go/sdk/client/network"
 	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
 )
 
 // IClientMessenger defines the interface for client-side message communication
@@ -40,6 +44,15 @@ type IClientMessenger interface {
 	//   - updated - Flag indicating if documents have been updated
 	BroadcastDocuments(updated bool)
 
+	// RotateVisaKey replaces the current user's visa key and broadcasts the new visa
+	//
+	// Generates a new key pair, re-signs the visa with the identity key,
+	// stores the new private key in the front, then sends the visa to all contacts;
+	// the old keys keep decrypting until the grace period expired
+	//
+	// Returns: false if visa not updated
+	RotateVisaKey() bool
+
 	// BroadcastLogin sends a login command to maintain roaming state across stations
 	//
 	// Keeps the client's login state active across network stations (roaming support)
@@ -64,6 +77,37 @@ type IClientMessenger interface {
 	// Parameters:
 	//   - sender - User ID to mark as offline
 	ReportOffline(sender ID)
+
+	// QueryPresence asks the station for a contact's online status and last seen time
+	//
+	// The response will be posted as notification "presence_updated"
+	//
+	// Parameters:
+	//   - contact - User ID to query
+	QueryPresence(contact ID) bool
+
+	// SubscribePresence asks the station to push online/offline events of the contacts
+	//
+	// Replaces the old subscriptions, send an empty list to unsubscribe all
+	//
+	// Parameters:
+	//   - contacts - User IDs to watch
+	SubscribePresence(contacts []ID) bool
+
+	// SendTyping tells the receiver that the current user starts/stops typing
+	//
+	// Parameters:
+	//   - receiver - Contact (or group) ID of the conversation
+	//   - typing   - true for start, false for stop
+	SendTyping(receiver ID, typing bool) bool
+
+	// SendMessageReceipt tells the sender that the message was delivered/read
+	//
+	// Parameters:
+	//   - act     - Receipt action ("delivered" or "read")
+	//   - content - Content of the received message
+	//   - rMsg    - Received message (for the sender and the signature)
+	SendMessageReceipt(act string, content Content, rMsg ReliableMessage) bool
 }
 
 // ClientMessenger implements the IClientMessenger interface
@@ -77,7 +121,118 @@ type ClientMessenger struct {
 }
 
 func NewClientMessenger(session Session, facebook ICommonFacebook, database CipherKeyDelegate) *ClientMessenger {
-	return &ClientMessenger{
+	messenger := &ClientMessenger{
 		CommonMessenger: NewCommonMessenger(session, facebook, database),
 	}
+	// send entity queries & responses via this messenger,
+	// unless the app has already installed its own emitter
+	if checker, ok := facebook.GetEntityChecker().(*EntityChecker); ok && checker.Request == nil {
+		emitter := NewClientCheckEmitter(messenger)
+		checker.Request = emitter
+		if checker.Respond == nil {
+			checker.Respond = emitter
+		}
+	}
+	return messenger
+}
+
+// Override
+func (messenger *ClientMessenger) BroadcastDocuments(updated bool) {
+	facebook := messenger.GetFacebook()
+	user := facebook.GetCurrentUser()
+	if user == nil {
+		//panic("current user not found")
+		return
+	}
+	uid := user.ID()
+	visa := facebook.GetVisa(uid)
+	if visa == nil {
+		//panic("visa not found: " + uid.String())
+		return
+	}
+	checker := facebook.GetEntityChecker()
+	//
+	//  send to all contacts,
+	//  each contact is limited by the response frequency unless visa updated
+	//
+	contacts := facebook.GetContacts(uid)
+	for _, contact := range contacts {
+		checker.SendVisa(visa, contact, updated)
+	}
+	//
+	//  broadcast to 'archivist@anywhere',
+	//  force to send if visa updated
+	//
+	archivist := ParseID(AnyArchivist)
+	checker.SendVisa(visa, archivist, updated)
+}
+
+// Override
+func (messenger *ClientMessenger) RotateVisaKey() bool {
+	facebook := messenger.GetFacebook()
+	user := facebook.GetCurrentUser()
+	if user == nil {
+		//panic("current user not found")
+		return false
+	}
+	visa := facebook.RotateVisaKey(user.ID())
+	if visa == nil {
+		return false
+	}
+	messenger.BroadcastDocuments(true)
+	return true
+}
+
+// Override
+func (messenger *ClientMessenger) ReportOnline(sender ID) {
+	messenger.sendReport(ONLINE, sender)
+}
+
+// Override
+func (messenger *ClientMessenger) ReportOffline(sender ID) {
+	messenger.sendReport(OFFLINE, sender)
+}
+
+// private
+func (messenger *ClientMessenger) sendReport(title string, sender ID) {
+	content := NewReportCommand(title)
+	station := ParseID(AnyStation)
+	messenger.SendContent(content, sender, station, 1)
+}
+
+// Override
+func (messenger *ClientMessenger) QueryPresence(contact ID) bool {
+	content := NewPresenceCommand(contact)
+	station := ParseID(AnyStation)
+	pair := messenger.SendContent(content, nil, station, 1)
+	return pair.Second() != nil
+}
+
+// Override
+func (messenger *ClientMessenger) SubscribePresence(contacts []ID) bool {
+	if contacts == nil {
+		contacts = make([]ID, 0)
+	}
+	content := NewSubscribeCommand(contacts)
+	station := ParseID(AnyStation)
+	pair := messenger.SendContent(content, nil, station, 1)
+	return pair.Second() != nil
+}
+
+// Override
+func (messenger *ClientMessenger) SendTyping(receiver ID, typing bool) bool {
+	var group ID
+	if receiver.IsGroup() {
+		group = receiver
+	}
+	content := NewTypingContent(typing, group)
+	pair := messenger.SendContent(content, nil, receiver, 1)
+	return pair.Second() != nil
+}
+
+// Override
+func (messenger *ClientMessenger) SendMessageReceipt(act string, content Content, rMsg ReliableMessage) bool {
+	receipt := NewMessageReceipt(act, content, rMsg)
+	pair := messenger.SendContent(receipt, nil, rMsg.Sender(), 1)
+	return pair.Second() != nil
 }
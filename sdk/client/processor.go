@@ -6,6 +6,7 @@ import (
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/sdk-go/sdk"
 	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
 	. "github.com/dimpart/demo-go/sdk/common/protocol"
 	. "github.com/dimpart/demo-go/sdk/utils"
 )
@@ -20,6 +21,87 @@ func NewClientMessageProcessor(facebook Facebook, messenger Messenger) *ClientMe
 	}
 }
 
+// Override
+func (processor *ClientMessageProcessor) ProcessReliableMessage(rMsg ReliableMessage) []ReliableMessage {
+	if processor.isBlocked(rMsg) {
+		LogInfo("drop message from blocked sender: " + rMsg.Sender().String())
+		return nil
+	}
+	return processor.CommonMessageProcessor.ProcessReliableMessage(rMsg)
+}
+
+// private
+func (processor *ClientMessageProcessor) isBlocked(rMsg ReliableMessage) bool {
+	facebook := processor.GetFacebook()
+	db, ok := facebook.GetDatabase().(BlockDBI)
+	if !ok {
+		// block-list not supported
+		return false
+	}
+	var user ID
+	receiver := rMsg.Receiver()
+	if receiver.IsUser() && !receiver.IsBroadcast() {
+		user = receiver
+	} else if current := facebook.GetCurrentUser(); current != nil {
+		user = current.ID()
+	} else {
+		return false
+	}
+	sender := rMsg.Sender()
+	group := rMsg.Group()
+	for _, item := range db.GetBlockList(user) {
+		if item.Equal(sender) {
+			return true
+		} else if group != nil && item.Equal(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Override
+func (processor *ClientMessageProcessor) ProcessInstantMessage(iMsg InstantMessage, rMsg ReliableMessage) []InstantMessage {
+	processor.saveMessage(iMsg)
+	return processor.CommonMessageProcessor.ProcessInstantMessage(iMsg, rMsg)
+}
+
+// private
+func (processor *ClientMessageProcessor) saveMessage(iMsg InstantMessage) bool {
+	content := iMsg.Content()
+	if _, ok := content.(Command); ok {
+		// no need to save command
+		return false
+	} else if app, ok := content.(CustomizedContent); ok && app.GetString("app", "") == StatusApp {
+		// no need to save typing indicators & receipts
+		return false
+	}
+	sender := iMsg.Sender()
+	if sender.Type() == STATION {
+		// message from station
+		return false
+	}
+	facebook := processor.GetFacebook()
+	db, ok := facebook.GetDatabase().(MessageHistoryDBI)
+	if !ok {
+		// chat history not supported
+		return false
+	}
+	var user ID
+	receiver := iMsg.Receiver()
+	if receiver.IsUser() && !receiver.IsBroadcast() {
+		user = receiver
+	} else if current := facebook.GetCurrentUser(); current != nil {
+		user = current.ID()
+	} else {
+		return false
+	}
+	chat := content.Group()
+	if chat == nil {
+		chat = sender
+	}
+	return db.SaveMessage(user, chat, iMsg, MessageDelivered)
+}
+
 func (processor *ClientMessageProcessor) checkGroupTimes(content Content, rMsg ReliableMessage) {
 	// TODO: check 'GDT' & 'GHT' in rMsg
 }
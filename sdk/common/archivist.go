@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
 	. "github.com/dimchat/plugins-go/mem"
@@ -9,6 +10,7 @@ import (
 	. "github.com/dimchat/sdk-go/sdk"
 	. "github.com/dimpart/demo-go/sdk/common/db"
 	. "github.com/dimpart/demo-go/sdk/common/mkm"
+	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
 type ICommonArchivist interface {
@@ -156,39 +158,63 @@ func (archivist *CommonArchivist) checkDocumentValid(doc Document, did ID) bool
 		// make sure the document time is not in the far future
 		nearFuture := TimeToFloat64(TimeNow()) + 1800
 		if TimeToFloat64(docTime) > nearFuture {
-			//panic("document time error")
+			archivist.warning("document time error: " + did.String())
 			return false
 		}
 	}
+	// check document ID
+	docID := GetDocumentID(doc)
+	if docID == nil {
+		archivist.warning("document ID not found: " + did.String())
+		return false
+	} else if !docID.Address().Equal(did.Address()) {
+		archivist.warning("document ID not matched: " + docID.String() + ", " + did.String())
+		return false
+	}
+	// check document type
+	if !archivist.checkDocumentType(doc, did) {
+		archivist.warning("document type not allowed: " + GetDocumentType(doc) + ", " + did.String())
+		return false
+	}
+	// check signature
+	data := doc.GetString("data", "")
+	signature := doc.GetString("signature", "")
+	if data == "" || signature == "" {
+		archivist.warning("document not signed: " + did.String())
+		return false
+	}
 	// check valid
 	return archivist.verifyDocument(doc, did)
 }
 
 // protected
-func (archivist *CommonArchivist) verifyDocument(doc Document, did ID) bool {
-	/*/
-	if doc.IsValid() {
-		return true
-	}
-	// check ID
-	docID := ParseID(doc.Get("did"))
-	if docID == nil {
-		//panic("document ID not found")
-		return false
-	} else if !docID.Address().Equal(did.Address()) {
-		// ID not matched
-		return false
+func (archivist *CommonArchivist) checkDocumentType(doc Document, did ID) bool {
+	docType := GetDocumentType(doc)
+	if did.IsUser() {
+		// 'profile' is the old version of 'visa'
+		return docType == VISA || docType == PROFILE
+	} else if did.IsGroup() {
+		return docType == BULLETIN
 	}
-	/*/
+	// unknown entity type
+	return false
+}
+
+// protected
+func (archivist *CommonArchivist) verifyDocument(doc Document, did ID) bool {
 	facebook := archivist.Facebook
 	// verify with meta.key
 	meta := facebook.GetMeta(did)
 	if meta == nil {
-		//panic("failed to get meta: " + did.String())
+		archivist.warning("failed to get meta: " + did.String())
 		return false
 	}
 	metaKey := meta.PublicKey()
-	return doc.Verify(metaKey)
+	if !doc.Verify(metaKey) {
+		archivist.warning("document signature not matched: " + did.String())
+		return false
+	}
+	return true
 }
 
 // protected
@@ -201,7 +227,11 @@ func (archivist *CommonArchivist) checkDocumentExpired(doc Document, did ID) boo
 	// check old documents with type
 	documents := facebook.GetDocuments(did)
 	old := GetLastDocument(documents, docType)
-	return old != nil && DocumentIsExpired(doc, old)
+	if old != nil && DocumentIsExpired(doc, old) {
+		archivist.warning("document time regressed: " + did.String())
+		return true
+	}
+	return false
 }
 
 // Override
@@ -226,5 +256,9 @@ func (archivist *CommonArchivist) ReduceMemory() int {
 	return cnt1 + cnt2 + cnt3 + cnt4
 }
 
-var sharedUserCache MemoryCache[string, User] = NewThanosCache[string, User]()
-var sharedGroupCache MemoryCache[string, Group] = NewThanosCache[string, Group]()
+func (archivist *CommonArchivist) warning(msg string) {
+	LogWarning("Archivist > " + msg)
+}
+
+var sharedUserCache = NewMeteredCache[string, User](NewThanosCache[string, User](), CacheMaxSize)
+var sharedGroupCache = NewMeteredCache[string, Group](NewThanosCache[string, Group](), CacheMaxSize)
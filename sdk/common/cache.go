@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/plugins-go/mem"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// default limits for the shared caches
+const (
+	CacheMaxSize        = 1 << 14   // entries in each shared cache
+	MemoryThreshold     = 512 << 20 // heap in use (bytes) to reduce caches
+	MemoryCheckInterval = 5 * time.Minute
+)
+
+var sharedAddressCache = NewMeteredCache[string, Address](GetAddressCache(), CacheMaxSize)
+var sharedIDCache = NewMeteredCache[string, ID](GetIDCache(), CacheMaxSize)
+
+func init() {
+	// replace the default caches for metrics
+	SetAddressCache(sharedAddressCache)
+	SetIDCache(sharedIDCache)
+}
+
+// ArchivistCacheStatistics holds the counters of all shared caches
+type ArchivistCacheStatistics struct {
+	Users     CacheStatistics
+	Groups    CacheStatistics
+	IDs       CacheStatistics
+	Addresses CacheStatistics
+}
+
+// GetCacheStatistics returns a snapshot of the shared cache counters
+func GetCacheStatistics() ArchivistCacheStatistics {
+	return ArchivistCacheStatistics{
+		Users:     sharedUserCache.GetStatistics(),
+		Groups:    sharedGroupCache.GetStatistics(),
+		IDs:       sharedIDCache.GetStatistics(),
+		Addresses: sharedAddressCache.GetStatistics(),
+	}
+}
+
+// SetCacheMaxSize limits the number of entries in each shared cache (0 = unlimited),
+// default is CacheMaxSize
+func SetCacheMaxSize(maxSize int) {
+	sharedUserCache.SetMaxSize(maxSize)
+	sharedGroupCache.SetMaxSize(maxSize)
+	sharedIDCache.SetMaxSize(maxSize)
+	sharedAddressCache.SetMaxSize(maxSize)
+}
+
+/**
+ *  Memory Monitor
+ *  ~~~~~~~~~~~~~~
+ *  Check Go runtime memory stats periodically,
+ *  reduce the shared caches when the heap grows over the threshold
+ *
+ *  It's not started automatically, the app should start it after the archivist created:
+ *
+ *      monitor := NewMemoryMonitor(archivist, MemoryThreshold, MemoryCheckInterval)
+ *      monitor.Start()
+ *      defer monitor.Stop()
+ */
+
+type MemoryMonitor struct {
+
+	// reduce caches when heap in use is over this size (bytes)
+	Threshold uint64
+	Interval  time.Duration
+
+	archivist *CommonArchivist
+
+	stop chan struct{}
+	lock sync.Mutex
+}
+
+func NewMemoryMonitor(archivist *CommonArchivist, threshold uint64, interval time.Duration) *MemoryMonitor {
+	return &MemoryMonitor{
+		Threshold: threshold,
+		Interval:  interval,
+		archivist: archivist,
+		stop:      nil,
+	}
+}
+
+// Start runs the periodic check in a background goroutine
+//
+// Returns: false if the interval is not positive, or already running
+func (monitor *MemoryMonitor) Start() bool {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	if monitor.Interval <= 0 {
+		LogError(fmt.Sprintf("Memory > check interval error: %v", monitor.Interval))
+		return false
+	} else if monitor.stop != nil {
+		// already running
+		return false
+	}
+	stop := make(chan struct{})
+	monitor.stop = stop
+	go monitor.run(stop, monitor.Interval)
+	return true
+}
+
+// Stop ends the background goroutine
+func (monitor *MemoryMonitor) Stop() {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	if monitor.stop != nil {
+		close(monitor.stop)
+		monitor.stop = nil
+	}
+}
+
+// private
+func (monitor *MemoryMonitor) run(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			monitor.Check()
+		}
+	}
+}
+
+// Check reduces the shared caches if memory in use is over the threshold
+//
+// Returns: number of survivors in caches (-1 if no need to reduce)
+func (monitor *MemoryMonitor) Check() int {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc < monitor.Threshold {
+		return -1
+	}
+	survivors := monitor.archivist.ReduceMemory()
+	LogWarning(fmt.Sprintf("Memory > heap alloc: %d, threshold: %d, cache survivors: %d",
+		stats.HeapAlloc, monitor.Threshold, survivors))
+	return survivors
+}
@@ -21,7 +21,9 @@ var (
 // Core responsibilities:
 //   - Track active group members and document/group history timestamps
 //   - Check if entity data (Meta/Documents/Group Members) needs to be queried/updated
+//   - Rate-limit outbound Visa responses to each contact
 type IEntityChecker interface {
+	IEntityRespond
 
 	// SetLastActiveMember records the most recently active member of a specific group
 	//
@@ -1,6 +1,9 @@
 package sdk
 
 import (
+	"sync"
+	"sync/atomic"
+
 	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
@@ -25,17 +28,25 @@ type EntityChecker struct {
 
 	// group => member (string = GID)
 	lastActiveMembers map[string]ID
+	membersLock       sync.RWMutex
+
+	// query counters
+	stats queryStats
 
 	// protected
 	database AccountDBI
 
+	// recent times storage (optional)
+	timesDB   CheckerDBI
+	timesLock sync.Mutex
+
 	// delegates
 	Request IEntityRequest
 	Respond IEntityRespond
 }
 
 func NewEntityChecker(db AccountDBI) *EntityChecker {
-	return &EntityChecker{
+	checker := &EntityChecker{
 		metaQueries:    NewFrequencyChecker[string](QUERY_EXPIRES),
 		docsQueries:    NewFrequencyChecker[string](QUERY_EXPIRES),
 		membersQueries: NewFrequencyChecker[string](QUERY_EXPIRES),
@@ -52,6 +63,25 @@ func NewEntityChecker(db AccountDBI) *EntityChecker {
 		Request: nil,
 		Respond: nil,
 	}
+	// restore recent times if the database supports it
+	if tdb, ok := db.(CheckerDBI); ok {
+		checker.timesDB = tdb
+		checker.loadRecentTimes()
+	}
+	return checker
+}
+
+// private
+func (checker *EntityChecker) loadRecentTimes() {
+	tdb := checker.timesDB
+	checker.timesLock.Lock()
+	defer checker.timesLock.Unlock()
+	for key, when := range tdb.GetLastDocumentTimes() {
+		checker.lastDocumentTimes.SetLastTime(key, when)
+	}
+	for key, when := range tdb.GetLastGroupHistoryTimes() {
+		checker.lastHistoryTimes.SetLastTime(key, when)
+	}
 }
 
 // protected
@@ -76,22 +106,47 @@ func (checker *EntityChecker) IsDocumentResponseExpired(did ID, force bool) bool
 
 // Override
 func (checker *EntityChecker) SetLastActiveMember(group, member ID) {
+	checker.membersLock.Lock()
+	defer checker.membersLock.Unlock()
 	checker.lastActiveMembers[group.String()] = member
 }
 
 // Override
 func (checker *EntityChecker) GetLastActiveMember(group ID) ID {
+	checker.membersLock.RLock()
+	defer checker.membersLock.RUnlock()
 	return checker.lastActiveMembers[group.String()]
 }
 
 // Override
 func (checker *EntityChecker) SetLastDocumentTime(did ID, current Time) bool {
-	return checker.lastDocumentTimes.SetLastTime(did.String(), current)
+	if !checker.lastDocumentTimes.SetLastTime(did.String(), current) {
+		return false
+	}
+	if tdb := checker.timesDB; tdb != nil {
+		checker.timesLock.Lock()
+		defer checker.timesLock.Unlock()
+		tdb.SaveLastDocumentTime(did, current)
+	}
+	return true
 }
 
 // Override
 func (checker *EntityChecker) SetLastGroupHistoryTime(gid ID, current Time) bool {
-	return checker.lastHistoryTimes.SetLastTime(gid.String(), current)
+	if !checker.lastHistoryTimes.SetLastTime(gid.String(), current) {
+		return false
+	}
+	if tdb := checker.timesDB; tdb != nil {
+		checker.timesLock.Lock()
+		defer checker.timesLock.Unlock()
+		tdb.SaveLastGroupHistoryTime(gid, current)
+	}
+	return true
+}
+
+// GetQueryStatistics returns a snapshot of the query counters
+func (checker *EntityChecker) GetQueryStatistics() QueryStatistics {
+	return checker.stats.snapshot()
 }
 
 //
@@ -101,11 +156,16 @@ func (checker *EntityChecker) SetLastGroupHistoryTime(gid ID, current Time) bool
 // Override
 func (checker *EntityChecker) CheckMeta(did ID, meta Meta) bool {
 	if checker.NeedsQueryMeta(did, meta) {
-		//if !checker.IsMetaQueryExpired(did) {
-		//	// query not expired yet
-		//	return false
-		//}
-		return checker.Request.QueryMeta(did)
+		if !checker.IsMetaQueryExpired(did) {
+			// query not expired yet
+			atomic.AddInt64(&checker.stats.metaSuppressed, 1)
+			return false
+		}
+		ok := checker.Request.QueryMeta(did)
+		if ok {
+			atomic.AddInt64(&checker.stats.metaSent, 1)
+		}
+		return ok
 	}
 	// no need to query meta again
 	return false
@@ -130,11 +190,16 @@ func (checker *EntityChecker) NeedsQueryMeta(did ID, meta Meta) bool {
 // Override
 func (checker *EntityChecker) CheckDocuments(did ID, documents []Document) bool {
 	if checker.NeedsQueryDocuments(did, documents) {
-		//if !checker.IsDocumentQueryExpired(did) {
-		//	// query not expired yet
-		//	return false
-		//}
-		return checker.Request.QueryDocuments(did, documents)
+		if !checker.IsDocumentQueryExpired(did) {
+			// query not expired yet
+			atomic.AddInt64(&checker.stats.docsSuppressed, 1)
+			return false
+		}
+		ok := checker.Request.QueryDocuments(did, documents)
+		if ok {
+			atomic.AddInt64(&checker.stats.docsSent, 1)
+		}
+		return ok
 	}
 	// no need to update documents now
 	return false
@@ -177,11 +242,16 @@ func (checker *EntityChecker) GetLastDocumentTime(did ID, documents []Document)
 // Override
 func (checker *EntityChecker) CheckMembers(group ID, members []ID) bool {
 	if checker.NeedsQueryMembers(group, members) {
-		//if !checker.IsMembersQueryExpired(group) {
-		//	// query not expired yet
-		//	return false
-		//}
-		return checker.Request.QueryMembers(group, members)
+		if !checker.IsMembersQueryExpired(group) {
+			// query not expired yet
+			atomic.AddInt64(&checker.stats.membersSuppressed, 1)
+			return false
+		}
+		ok := checker.Request.QueryMembers(group, members)
+		if ok {
+			atomic.AddInt64(&checker.stats.membersSent, 1)
+		}
+		return ok
 	}
 	// no need to update group members now
 	return false
@@ -223,3 +293,56 @@ func (checker *EntityChecker) GetLastGroupHistoryTime(group ID) Time {
 	}
 	return lastTime
 }
+
+//
+//  Visa Respond
+//
+
+// Override
+func (checker *EntityChecker) SendVisa(visa Visa, receiver ID, updated bool) bool {
+	respond := checker.Respond
+	if respond == nil {
+		//panic("entity respond not set")
+		return false
+	} else if !checker.IsDocumentResponseExpired(receiver, updated) {
+		// response not expired yet
+		return false
+	}
+	return respond.SendVisa(visa, receiver, updated)
+}
+
+//
+//  Query Statistics
+//
+
+// QueryStatistics holds the numbers of entity queries sent and suppressed
+// by the frequency checkers
+type QueryStatistics struct {
+	MetaSent       int64
+	MetaSuppressed int64
+
+	DocumentsSent       int64
+	DocumentsSuppressed int64
+
+	MembersSent       int64
+	MembersSuppressed int64
+}
+
+type queryStats struct {
+	metaSent, metaSuppressed       int64
+	docsSent, docsSuppressed       int64
+	membersSent, membersSuppressed int64
+}
+
+func (stats *queryStats) snapshot() QueryStatistics {
+	return QueryStatistics{
+		MetaSent:       atomic.LoadInt64(&stats.metaSent),
+		MetaSuppressed: atomic.LoadInt64(&stats.metaSuppressed),
+
+		DocumentsSent:       atomic.LoadInt64(&stats.docsSent),
+		DocumentsSuppressed: atomic.LoadInt64(&stats.docsSuppressed),
+
+		MembersSent:       atomic.LoadInt64(&stats.membersSent),
+		MembersSuppressed: atomic.LoadInt64(&stats.membersSuppressed),
+	}
+}
@@ -25,6 +25,18 @@ type MetaDBI interface {
 	SaveMeta(meta Meta, entity ID) bool
 }
 
+// EntityDBI defines the interface for enumerating stored entities
+type EntityDBI interface {
+
+	// GetEntities retrieves IDs of all entities (user/group) with meta stored locally
+	//
+	// NOTICE: it reads & parses every meta file under the storage root,
+	//         for offline tools (e.g. 'register list') only, not for the messenger
+	//
+	// Returns: Slice of entity IDs (empty slice if nothing stored)
+	GetEntities() []ID
+}
+
 // DocumentDBI defines the interface for document persistence operations
 //
 // Manages storage and retrieval of documents (Visa/Bulletin/etc.) associated with entity IDs
@@ -0,0 +1,39 @@
+package db
+
+import (
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// CheckerDBI defines the interface for entity checker state persistence
+//
+// Keeps the recent document/history times across restarts,
+// so that a freshly started client won't query everything again
+type CheckerDBI interface {
+
+	// GetLastDocumentTimes retrieves all recorded 'SDT' (Sender Document Time)
+	//
+	// Returns: Map from entity ID string to last document time (empty map if none)
+	GetLastDocumentTimes() map[string]Time
+
+	// SaveLastDocumentTime persists the 'SDT' (Sender Document Time) for an entity
+	//
+	// Parameters:
+	//   - did      - Entity ID (user/group) to save document time for
+	//   - lastTime - Last document time to persist
+	// Returns: true if time saved successfully, false on database error
+	SaveLastDocumentTime(did ID, lastTime Time) bool
+
+	// GetLastGroupHistoryTimes retrieves all recorded 'GHT' (Group History Time)
+	//
+	// Returns: Map from group ID string to last history time (empty map if none)
+	GetLastGroupHistoryTimes() map[string]Time
+
+	// SaveLastGroupHistoryTime persists the 'GHT' (Group History Time) for a group
+	//
+	// Parameters:
+	//   - gid      - Group ID to save history time for
+	//   - lastTime - Last group history time to persist
+	// Returns: true if time saved successfully, false on database error
+	SaveLastGroupHistoryTime(gid ID, lastTime Time) bool
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// ContactInfo holds the local settings of a contact
+type ContactInfo struct {
+	ID     ID
+	Remark string   // private note
+	Alias  string   // display name set by the user
+	Tags   []string // labels for grouping contacts
+}
+
+func ConvertContactInfo(array []StringKeyMap) []*ContactInfo {
+	contacts := make([]*ContactInfo, 0, len(array))
+	var info *ContactInfo
+	var did ID
+	var tags []string
+	for _, item := range array {
+		did = ParseID(item["did"])
+		if did == nil {
+			did = ParseID(item["ID"])
+			if did == nil {
+				continue
+			}
+		}
+		tags = make([]string, 0)
+		if list, ok := item["tags"].([]interface{}); ok {
+			for _, tag := range list {
+				if str := ConvertString(tag, ""); str != "" {
+					tags = append(tags, str)
+				}
+			}
+		}
+		info = &ContactInfo{
+			ID:     did,
+			Remark: ConvertString(item["remark"], ""),
+			Alias:  ConvertString(item["alias"], ""),
+			Tags:   tags,
+		}
+		contacts = append(contacts, info)
+	}
+	return contacts
+}
+
+func RevertContactInfo(contacts []*ContactInfo) []StringKeyMap {
+	array := make([]StringKeyMap, len(contacts))
+	for index, item := range contacts {
+		tags := item.Tags
+		if tags == nil {
+			tags = make([]string, 0)
+		}
+		array[index] = StringKeyMap{
+			"ID":     item.ID.String(),
+			"did":    item.ID.String(),
+			"remark": item.Remark,
+			"alias":  item.Alias,
+			"tags":   tags,
+		}
+	}
+	return array
+}
+
+// ContactInfoDBI defines the interface for contact settings persistence
+//
+// Manages remarks, alias names and tags of contacts for a specific user
+type ContactInfoDBI interface {
+
+	// GetContactInfos retrieves all contact settings of a user
+	//
+	// Parameters:
+	//   - user - User ID who owns the contacts
+	// Returns: Slice of contact settings (empty slice if not found)
+	GetContactInfos(user ID) []*ContactInfo
+
+	// SaveContactInfos overwrites all contact settings of a user
+	//
+	// Parameters:
+	//   - infos - Contact settings to save
+	//   - user  - User ID who owns the contacts
+	// Returns: true on success, false on database error
+	SaveContactInfos(infos []*ContactInfo, user ID) bool
+}
+
+// MuteDBI defines the interface for mute-list persistence
+//
+// Messages from muted contacts are still received, but won't be notified
+type MuteDBI interface {
+
+	// GetMuteList retrieves the muted contacts of a user
+	GetMuteList(user ID) []ID
+
+	// SaveMuteList overwrites the muted contacts of a user
+	SaveMuteList(muted []ID, user ID) bool
+}
+
+// BlockDBI defines the interface for block-list persistence
+//
+// Messages from blocked contacts will be dropped
+type BlockDBI interface {
+
+	// GetBlockList retrieves the blocked contacts of a user
+	GetBlockList(user ID) []ID
+
+	// SaveBlockList overwrites the blocked contacts of a user
+	SaveBlockList(blocked []ID, user ID) bool
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"strings"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// HistoryMessage wraps a decrypted message with its state
+//
+// State values follow the outbox (sending -> sent -> delivered -> read),
+// received messages are saved with state 'MessageDelivered'
+type HistoryMessage struct {
+	Message InstantMessage
+	State   int
+}
+
+func (msg *HistoryMessage) SN() SerialNumberType {
+	return msg.Message.Content().SN()
+}
+
+func (msg *HistoryMessage) Sender() ID {
+	return msg.Message.Sender()
+}
+
+// Is checks whether it's the message with sender & serial number
+func (msg *HistoryMessage) Is(sender ID, sn SerialNumberType) bool {
+	return msg.SN() == sn && msg.Sender().Equal(sender)
+}
+
+func (msg *HistoryMessage) Time() Time {
+	return msg.Message.Time()
+}
+
+// Text returns the searchable text of the message content:
+// body of text message, or name of the file
+func (msg *HistoryMessage) Text() string {
+	content := msg.Message.Content()
+	if text, ok := content.(TextContent); ok {
+		return text.Text()
+	} else if file, ok := content.(FileContent); ok {
+		return file.Filename()
+	}
+	return ""
+}
+
+// Match checks whether the message text contains the keyword (case-insensitive)
+func (msg *HistoryMessage) Match(keyword string) bool {
+	text := msg.Text()
+	if text == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(text), strings.ToLower(keyword))
+}
+
+func ConvertHistoryMessages(array []StringKeyMap) []*HistoryMessage {
+	messages := make([]*HistoryMessage, 0, len(array))
+	for _, item := range array {
+		iMsg := ParseInstantMessage(item["msg"])
+		if iMsg == nil {
+			continue
+		}
+		messages = append(messages, &HistoryMessage{
+			Message: iMsg,
+			State:   ConvertInt(item["state"], MessageSending),
+		})
+	}
+	return messages
+}
+
+func RevertHistoryMessages(messages []*HistoryMessage) []StringKeyMap {
+	array := make([]StringKeyMap, len(messages))
+	for index, item := range messages {
+		array[index] = StringKeyMap{
+			"msg":   item.Message.Map(),
+			"state": item.State,
+		}
+	}
+	return array
+}
+
+// MessageHistoryDBI defines the interface for the local chat history
+//
+// Messages are grouped by user (owner of the history) and conversation (chat ID),
+// the conversation is the contact for personal messages, or the group for group messages
+type MessageHistoryDBI interface {
+
+	// GetConversations returns chat IDs of the user's history, the latest first
+	GetConversations(user ID) []ID
+
+	// SaveMessage inserts a message into the conversation in time order,
+	// or replaces the one with the same sender & serial number
+	SaveMessage(user, chat ID, iMsg InstantMessage, state int) bool
+
+	// UpdateMessageState updates the state of the message in the conversation
+	//
+	// The state can only move forward (sending -> sent -> delivered -> read)
+	//
+	// Returns: true if state changed
+	UpdateMessageState(user, chat, sender ID, sn SerialNumberType, state int) bool
+
+	// GetMessage finds the message by sender & serial number
+	// (serial number is only unique for each sender)
+	//
+	// Returns: nil if not found
+	GetMessage(user, chat, sender ID, sn SerialNumberType) *HistoryMessage
+
+	// GetMessagesBeforeTime returns messages earlier than the given time,
+	// ordered from old to new; nil time means from the latest one
+	GetMessagesBeforeTime(user, chat ID, before Time, limit int) []*HistoryMessage
+
+	// GetMessagesBeforeSN returns messages earlier than the one with serial number,
+	// ordered from old to new; zero means from the latest one
+	GetMessagesBeforeSN(user, chat ID, sn SerialNumberType, limit int) []*HistoryMessage
+
+	// SearchMessages returns messages containing the keyword, the latest first;
+	// nil chat means searching all conversations of the user
+	SearchMessages(user, chat ID, keyword string, limit int) []*HistoryMessage
+
+	// RemoveMessage deletes a message (with sender & serial number) from the conversation
+	RemoveMessage(user, chat, sender ID, sn SerialNumberType) bool
+
+	// RemoveConversation deletes all messages of the conversation
+	RemoveConversation(user, chat ID) bool
+}
+
+// MessageQuery describes the conditions for searching messages
+type MessageQuery struct {
+	Keyword string
+
+	// conversation filter, nil for all conversations
+	Chat ID
+
+	// time range, nil for unlimited
+	Start Time
+	End   Time
+
+	// max results, 0 for unlimited
+	Limit int
+}
+
+// MessageIndexDBI defines the interface for the full-text index of chat history
+//
+// The index is updated incrementally when messages saved or removed
+type MessageIndexDBI interface {
+
+	// QueryMessages returns messages matching all words in the keyword, the latest first;
+	// each word matches the terms start with it, e.g.: "hel" for "hello"
+	QueryMessages(user ID, query *MessageQuery) []*HistoryMessage
+
+	// RebuildIndex drops the index and builds it again from the chat history
+	RebuildIndex(user ID) bool
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+// Delivery states of the outgoing message
+const (
+	MessageSending   = 0 // waiting for the station's receipt
+	MessageSent      = 1 // station received
+	MessageDelivered = 2 // receiver received
+	MessageRead      = 3 // receiver read
+)
+
+// OutgoingMessage records the delivery state of a sent message
+type OutgoingMessage struct {
+	Sender   ID
+	Receiver ID
+	SN       SerialNumberType
+	// digest of the message signature
+	Signature string
+	Time      Time
+	State     int
+}
+
+func ConvertOutgoingMessages(array []StringKeyMap) []*OutgoingMessage {
+	messages := make([]*OutgoingMessage, 0, len(array))
+	var sender, receiver ID
+	for _, item := range array {
+		sender = ParseID(item["sender"])
+		receiver = ParseID(item["receiver"])
+		if sender == nil || receiver == nil {
+			continue
+		}
+		messages = append(messages, &OutgoingMessage{
+			Sender:    sender,
+			Receiver:  receiver,
+			SN:        ConvertUInt64(item["sn"], 0),
+			Signature: ConvertString(item["signature"], ""),
+			Time:      ConvertTime(item["time"], nil),
+			State:     ConvertInt(item["state"], MessageSending),
+		})
+	}
+	return messages
+}
+
+func RevertOutgoingMessages(messages []*OutgoingMessage) []StringKeyMap {
+	array := make([]StringKeyMap, len(messages))
+	for index, item := range messages {
+		array[index] = StringKeyMap{
+			"sender":    item.Sender.String(),
+			"receiver":  item.Receiver.String(),
+			"sn":        item.SN,
+			"signature": item.Signature,
+			"time":      TimeToFloat64(item.Time),
+			"state":     item.State,
+		}
+	}
+	return array
+}
+
+// OutboxDBI defines the interface for the outbound message log
+//
+// Keeps the delivery states of sent messages for matching receipts
+type OutboxDBI interface {
+
+	// SaveOutgoingMessage appends a sent message to the sender's log
+	SaveOutgoingMessage(msg *OutgoingMessage) bool
+
+	// GetOutgoingMessage finds the sent message by envelope & serial number
+	//
+	// Returns: nil if not found
+	GetOutgoingMessage(sender, receiver ID, sn SerialNumberType) *OutgoingMessage
+
+	// UpdateOutgoingMessageState updates the delivery state of the sent message
+	//
+	// The state can only move forward (sending -> sent -> delivered -> read)
+	//
+	// Returns: true if state changed
+	UpdateOutgoingMessageState(sender, receiver ID, sn SerialNumberType, state int) bool
+}
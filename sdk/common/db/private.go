@@ -4,6 +4,7 @@ import (
 	. "github.com/dimchat/mkm-go/crypto"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
 //goland:noinspection GoSnakeCaseUsage
@@ -57,6 +58,25 @@ type PrivateKeyDBI interface {
 	GetPrivateKeyForVisaSignature(user ID) SignKey
 }
 
+// VisaKeyGracePeriodDays is how long (in days) a retired communication key keeps decrypting
+const VisaKeyGracePeriodDays = 7
+
+// VisaKeyRotationDBI defines the interface for retiring old communication keys
+//
+// When a new visa key saved to the front, the previous one is marked as retired;
+// messages encrypted by contacts with the old visa can still be decrypted
+// until the grace period expired
+type VisaKeyRotationDBI interface {
+
+	// PruneCommunicationKeys removes the communication keys retired longer than the grace period
+	//
+	// Parameters:
+	//   - user  - Local user ID
+	//   - grace - How long the retired keys should be kept
+	// Returns: count of keys removed
+	PruneCommunicationKeys(user ID, grace Duration) int
+}
+
 //
 //  Conveniences
 //
@@ -154,6 +154,58 @@ func NewReportCommandWithMap(dict StringKeyMap) Command {
 	return NewBaseReportCommand(dict, "")
 }
 
+/**
+ *  Presence Command
+ *
+ *  data format: {
+ *      type : 0x88,
+ *      sn   : 123,
+ *
+ *      command   : "presence",
+ *      did       : "{ID}",        // contact to query
+ *      //---- response from station
+ *      online    : true,
+ *      last_seen : 1234567890,    // timestamp
+ *  }
+ */
+
+func NewPresenceCommand(did ID) PresenceCommand {
+	return NewBasePresenceCommand(nil, did)
+}
+
+func NewPresenceResponse(did ID, online bool, lastSeen Time) PresenceCommand {
+	content := NewBasePresenceCommand(nil, did)
+	content.Set("online", online)
+	if !TimeIsNil(lastSeen) {
+		content.SetTime("last_seen", lastSeen)
+	}
+	return content
+}
+
+func NewPresenceCommandWithMap(dict StringKeyMap) Command {
+	return NewBasePresenceCommand(dict, nil)
+}
+
+/**
+ *  Subscribe Command
+ *
+ *  data format: {
+ *      type : 0x88,
+ *      sn   : 123,
+ *
+ *      command : "subscribe",
+ *      list    : []      // users to watch
+ *  }
+ */
+
+func NewSubscribeCommand(list []ID) SubscribeCommand {
+	return NewBaseSubscribeCommand(nil, list)
+}
+
+func NewSubscribeCommandWithMap(dict StringKeyMap) Command {
+	return NewBaseSubscribeCommand(dict, nil)
+}
+
 /**
  *  Application Customized Content
  */
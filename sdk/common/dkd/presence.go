@@ -0,0 +1,110 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/core-go/dkd"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+)
+
+type BasePresenceCommand struct {
+	//PresenceCommand
+	*BaseCommand
+}
+
+func NewBasePresenceCommand(dict StringKeyMap, did ID) *BasePresenceCommand {
+	if dict != nil {
+		// init presence command with map
+		return &BasePresenceCommand{
+			BaseCommand: NewBaseCommand(dict, "", ""),
+		}
+	}
+	// new presence command
+	content := &BasePresenceCommand{
+		BaseCommand: NewBaseCommand(nil, "", PRESENCE),
+	}
+	content.Set("did", did.String())
+	return content
+}
+
+// Override
+func (content *BasePresenceCommand) ID() ID {
+	return ParseID(content.Get("did"))
+}
+
+// Override
+func (content *BasePresenceCommand) IsOnline() bool {
+	return content.GetBool("online", false)
+}
+
+// Override
+func (content *BasePresenceCommand) LastSeen() Time {
+	return content.GetTime("last_seen", nil)
+}
+
+type BaseSubscribeCommand struct {
+	//SubscribeCommand
+	*BaseCommand
+
+	// subscribe-list
+	list []ID
+}
+
+func NewBaseSubscribeCommand(dict StringKeyMap, list []ID) *BaseSubscribeCommand {
+	if dict != nil {
+		// init subscribe command with map
+		return &BaseSubscribeCommand{
+			BaseCommand: NewBaseCommand(dict, "", ""),
+			// lazy load
+			list: nil,
+		}
+	}
+	// new subscribe command
+	content := &BaseSubscribeCommand{
+		BaseCommand: NewBaseCommand(nil, "", SUBSCRIBE),
+		list:        list,
+	}
+	content.Set("list", IDRevert(list))
+	return content
+}
+
+// Override
+func (content *BaseSubscribeCommand) SubscribeList() []ID {
+	if content.list == nil {
+		list := content.Get("list")
+		if list != nil {
+			content.list = IDConvert(list)
+		}
+	}
+	return content.list
+}
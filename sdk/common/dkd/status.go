@@ -0,0 +1,142 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package dkd
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+)
+
+/**
+ *  Typing Indicator
+ *
+ *  data format: {
+ *      type : i2s(0xCC),
+ *      sn   : 123,
+ *
+ *      app   : "chat.dim.status",
+ *      mod   : "typing",
+ *      act   : "start",           // or "stop"
+ *      group : "{GROUP_ID}",      // optional
+ *  }
+ */
+
+func NewTypingContent(typing bool, group ID) CustomizedContent {
+	act := TypingStop
+	if typing {
+		act = TypingStart
+	}
+	content := NewCustomizedContent(StatusApp, TypingModule, act)
+	if group != nil {
+		content.SetGroup(group)
+	}
+	return content
+}
+
+/**
+ *  Message Receipt
+ *
+ *  data format: {
+ *      type : i2s(0xCC),
+ *      sn   : 456,
+ *
+ *      app    : "chat.dim.status",
+ *      mod    : "receipt",
+ *      act    : "read",           // or "delivered"
+ *      group  : "{GROUP_ID}",     // optional
+ *      origin : {
+ *          sn        : 123,       // serial number of the original content
+ *          signature : "..."      // digest of the original message signature
+ *      }
+ *  }
+ */
+
+func NewMessageReceipt(act string, content Content, rMsg ReliableMessage) CustomizedContent {
+	receipt := NewCustomizedContent(StatusApp, ReceiptModule, act)
+	origin := NewMap()
+	origin["sn"] = content.SN()
+	origin["signature"] = GetSignatureDigest(rMsg.GetString("signature", ""))
+	receipt.Set("origin", origin)
+	group := content.Group()
+	if group != nil {
+		receipt.SetGroup(group)
+	}
+	return receipt
+}
+
+/**
+ *  Presence Event
+ *
+ *  data format: {
+ *      type : i2s(0xCC),
+ *      sn   : 789,
+ *
+ *      app       : "chat.dim.status",
+ *      mod       : "presence",
+ *      act       : "online",      // or "offline"
+ *      did       : "{ID}",
+ *      last_seen : 123.456,       // optional
+ *  }
+ */
+
+func NewPresenceEvent(did ID, online bool, lastSeen Time) CustomizedContent {
+	act := PresenceOffline
+	if online {
+		act = PresenceOnline
+	}
+	content := NewCustomizedContent(StatusApp, PresenceModule, act)
+	content.Set("did", did.String())
+	if !TimeIsNil(lastSeen) {
+		content.SetTime("last_seen", lastSeen)
+	}
+	return content
+}
+
+// GetSignatureDigest returns the last 8 characters of the signature (base64)
+func GetSignatureDigest(signature string) string {
+	if len(signature) > 8 {
+		return signature[len(signature)-8:]
+	}
+	return signature
+}
+
+// GetReceiptOrigin returns the serial number & signature digest of the original message
+func GetReceiptOrigin(receipt CustomizedContent) (SerialNumberType, string) {
+	origin, ok := receipt.Get("origin").(StringKeyMap)
+	if !ok {
+		return 0, ""
+	}
+	sn := ConvertUInt64(origin["sn"], 0)
+	signature := ConvertString(origin["signature"], "")
+	return sn, signature
+}
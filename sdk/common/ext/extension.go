@@ -129,6 +129,10 @@ func registerCommandFactories() {
 	registerCommandCreator(ONLINE, NewReportCommandWithMap)
 	registerCommandCreator(OFFLINE, NewReportCommandWithMap)
 
+	// Presence
+	registerCommandCreator(PRESENCE, NewPresenceCommandWithMap)
+	registerCommandCreator(SUBSCRIBE, NewSubscribeCommandWithMap)
+
 }
 
 func registerCommandCreator(cmd string, fn FuncCreateCommand) {
@@ -1,15 +1,19 @@
 package sdk
 
 import (
+	"sync"
+
 	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/mkm-go/crypto"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/plugins-go/crypto"
 	. "github.com/dimchat/sdk-go/mkm"
 	. "github.com/dimchat/sdk-go/sdk"
 	. "github.com/dimpart/demo-go/sdk/common/db"
 	. "github.com/dimpart/demo-go/sdk/common/mkm"
 	. "github.com/dimpart/demo-go/sdk/extensions"
+	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
 type ICommonFacebook interface {
@@ -18,6 +22,13 @@ type ICommonFacebook interface {
 	GetDatabase() AccountDBI
 	GetEntityChecker() IEntityChecker
 
+	//
+	//  Local Users
+	//
+	GetLocalUsers() []ID
+	AddLocalUser(uid ID) bool
+	RemoveLocalUser(uid ID) bool
+
 	//
 	//  Current User
 	//
@@ -31,6 +42,15 @@ type ICommonFacebook interface {
 	GetVisa(uid ID) Visa
 	GetBulletin(gid ID) Bulletin
 
+	// UpdateVisa signs the modified visa with the user's visa key and saves it
+	UpdateVisa(visa Visa) bool
+
+	// RotateVisaKey replaces the communication key in the user's visa with a new one,
+	// the old keys will still be used for decrypting until the grace period expired
+	//
+	// Returns: the new visa, nil on failure
+	RotateVisaKey(uid ID) Visa
+
 	GetName(did ID) string
 }
 
@@ -49,6 +69,7 @@ type CommonFacebook struct {
 	Checker IEntityChecker
 
 	currentUser User
+	userLock    sync.Mutex // guards currentUser & local users
 }
 
 func NewCommonFacebook(db EntityDataSource) *CommonFacebook {
@@ -68,28 +89,111 @@ func (facebook *CommonFacebook) GetEntityChecker() IEntityChecker {
 	return facebook.Checker
 }
 
+//
+//  Local Users
+//
+
+func (facebook *CommonFacebook) GetLocalUsers() []ID {
+	return facebook.Database.GetLocalUsers()
+}
+
+// AddLocalUser appends a user with private key to the local users,
+// the current user will not be changed
+func (facebook *CommonFacebook) AddLocalUser(uid ID) bool {
+	if !uid.IsUser() || uid.IsBroadcast() {
+		return false
+	} else if facebook.GetPrivateKeyForSignature(uid) == nil {
+		//panic("private key not found: " + uid.String())
+		return false
+	}
+	facebook.userLock.Lock()
+	defer facebook.userLock.Unlock()
+	users := facebook.GetLocalUsers()
+	for _, item := range users {
+		if item.Equal(uid) {
+			// duplicated
+			return false
+		}
+	}
+	array := make([]ID, 0, len(users)+1)
+	array = append(array, users...)
+	array = append(array, uid)
+	return facebook.Database.SaveLocalUsers(array)
+}
+
+// RemoveLocalUser removes a user from the local users,
+// if it's the current user, the next one will be current
+func (facebook *CommonFacebook) RemoveLocalUser(uid ID) bool {
+	facebook.userLock.Lock()
+	defer facebook.userLock.Unlock()
+	users := facebook.GetLocalUsers()
+	array := make([]ID, 0, len(users))
+	for _, item := range users {
+		if !item.Equal(uid) {
+			array = append(array, item)
+		}
+	}
+	if len(array) == len(users) {
+		// user not found
+		return false
+	}
+	current := facebook.currentUser
+	if current != nil && current.ID().Equal(uid) {
+		facebook.currentUser = nil
+	}
+	return facebook.Database.SaveLocalUsers(array)
+}
+
 //
 //  Current User
 //
 
 func (facebook *CommonFacebook) GetCurrentUser() User {
+	facebook.userLock.Lock()
 	currentUser := facebook.currentUser
+	facebook.userLock.Unlock()
 	if currentUser != nil {
 		return currentUser
 	}
-	users := facebook.Database.GetLocalUsers()
+	users := facebook.GetLocalUsers()
 	if len(users) == 0 {
 		return nil
 	}
-	currentUser = facebook.GetUser(users[0])
-	facebook.currentUser = currentUser
-	return currentUser
+	// get user without lock, it may call back to the facebook
+	user := facebook.GetUser(users[0])
+	facebook.userLock.Lock()
+	defer facebook.userLock.Unlock()
+	if facebook.currentUser == nil {
+		facebook.currentUser = user
+	}
+	return facebook.currentUser
 }
 
+// SetCurrentUser switches the current user,
+// which will be moved to the front of local users;
+// a user without private key will be ignored
 func (facebook *CommonFacebook) SetCurrentUser(user User) {
 	//if user.DataSource() == nil {
 	//	user.SetDataSource(facebook)
 	//}
+	uid := user.ID()
+	if facebook.GetPrivateKeyForSignature(uid) == nil {
+		LogError("Facebook > private key not found, cannot set current user: " + uid.String())
+		return
+	}
+	facebook.userLock.Lock()
+	defer facebook.userLock.Unlock()
+	users := facebook.GetLocalUsers()
+	array := make([]ID, 0, len(users)+1)
+	array = append(array, uid)
+	for _, item := range users {
+		if !item.Equal(uid) {
+			array = append(array, item)
+		}
+	}
+	if !facebook.Database.SaveLocalUsers(array) {
+		LogError("Facebook > failed to save local users, current user: " + uid.String())
+	}
 	facebook.currentUser = user
 }
 
@@ -152,6 +256,82 @@ func (facebook *CommonFacebook) GetBulletin(gid ID) Bulletin {
 	return GetLastBulletin(documents)
 }
 
+func (facebook *CommonFacebook) UpdateVisa(visa Visa) bool {
+	uid := GetDocumentID(visa)
+	if uid == nil || !uid.IsUser() {
+		//panic("visa ID error")
+		return false
+	}
+	user := facebook.GetUser(uid)
+	if user == nil {
+		//panic("user not found: " + uid.String())
+		return false
+	}
+	// NOTICE: sign with the private key paired with meta.key
+	if user.SignVisa(visa) == nil {
+		//panic("failed to sign visa: " + uid.String())
+		return false
+	}
+	return facebook.SaveDocument(visa, uid)
+}
+
+func (facebook *CommonFacebook) RotateVisaKey(uid ID) Visa {
+	visa := facebook.GetVisa(uid)
+	if visa == nil {
+		//panic("visa not found: " + uid.String())
+		return nil
+	}
+	db := facebook.Database
+	// 1. generate new key pair for visa
+	msgKey := GeneratePrivateKey(RSA)
+	visaKey, ok := msgKey.PublicKey().(EncryptKey)
+	if !ok {
+		//panic("visa key error")
+		return nil
+	}
+	doc, ok := ParseDocument(visa.CopyMap(false)).(Visa)
+	if !ok {
+		//panic("failed to copy visa: " + uid.String())
+		return nil
+	}
+	doc.SetPublicKey(visaKey)
+	// 2. store the new private key in the front,
+	//    the previous one will be retired
+	if !db.SavePrivateKey(msgKey, VISA_KEY, uid) {
+		//panic("failed to save visa key: " + uid.String())
+		return nil
+	}
+	// 3. sign the new visa with identity key and save it
+	if !facebook.UpdateVisa(doc) {
+		// roll back, move the old key to the front again
+		if oldKey := facebook.findVisaKey(uid, visa); oldKey != nil {
+			db.SavePrivateKey(oldKey, VISA_KEY, uid)
+		}
+		return nil
+	}
+	// 4. drop the keys retired too long ago
+	if keyDB, ok := db.(VisaKeyRotationDBI); ok {
+		keyDB.PruneCommunicationKeys(uid, DurationOfDays(VisaKeyGracePeriodDays))
+	}
+	return doc
+}
+
+// private
+func (facebook *CommonFacebook) findVisaKey(uid ID, visa Visa) PrivateKey {
+	visaKey := visa.PublicKey()
+	if visaKey == nil {
+		return nil
+	}
+	keys := facebook.Database.GetPrivateKeysForDecryption(uid)
+	for _, item := range keys {
+		if MatchEncryptKey(visaKey, item) {
+			key, _ := item.(PrivateKey)
+			return key
+		}
+	}
+	return nil
+}
+
 func (facebook *CommonFacebook) GetName(did ID) string {
 	var docType string
 	if did.IsUser() {
@@ -0,0 +1,87 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package protocol
+
+import (
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+)
+
+const PRESENCE = "presence"
+
+// PresenceCommand defines the interface for querying a contact's presence
+//
+// # Implements the Command interface for DIM network presence queries
+//
+//	Data Format: {
+//	    "type": 0x88,
+//	    "sn": 123,
+//
+//	    "command": "presence",
+//	    "did": "{ID}",              // contact to query
+//	    //---- response from station
+//	    "online": true,
+//	    "last_seen": 1234567890     // Unix epoch seconds
+//	}
+type PresenceCommand interface {
+	Command
+
+	// ID returns the contact ID being queried
+	ID() ID
+
+	// IsOnline returns whether the contact has active sessions on the station
+	IsOnline() bool
+
+	// LastSeen returns the last time the contact was seen (nil for query)
+	LastSeen() Time
+}
+
+const SUBSCRIBE = "subscribe"
+
+// SubscribeCommand defines the interface for presence subscriptions
+//
+// # The station will push online/offline events of these users as customized contents
+// ("chat.dim.status:presence"), only to the contacts who are not blocked
+//
+//	Data Format: {
+//	    "type": 0x88,
+//	    "sn": 123,
+//
+//	    "command": "subscribe",
+//	    "list": []       // users to watch, replaces the old subscriptions
+//	}
+type SubscribeCommand interface {
+	Command
+
+	// SubscribeList returns the list of user IDs to watch
+	SubscribeList() []ID
+}
@@ -0,0 +1,88 @@
+/* license: https://mit-license.org
+ *
+ *  DIMP : Decentralized Instant Messaging Protocol
+ *
+ *                                Written in 2026 by Moky <albert.moky@gmail.com>
+ *
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package protocol
+
+/*
+Status Contents:
+
+	+===============================+===============================+
+	|     Typing Indicator          |     Message Receipt           |
+	+-------------------------------+-------------------------------+
+	|   "type" : i2s(0xCC)          |   "type" : i2s(0xCC)          |
+	|   "sn"   : 123                |   "sn"   : 456                |
+	|   "time" : 123.456            |   "time" : 123.456            |
+	|   "app"  : "chat.dim.status"  |   "app"  : "chat.dim.status"  |
+	|   "mod"  : "typing"           |   "mod"  : "receipt"          |
+	|   "act"  : "start"            |   "act"  : "read"             |
+	|                               |                               |
+	|   "group" : "{GROUP_ID}"      |   "group" : "{GROUP_ID}"      |
+	|                               |   "origin" : {                |
+	|                               |       "sn"        : 123,      |
+	|                               |       "signature" : "..."     |
+	|                               |   }                           |
+	+===============================+===============================+
+
+	typing acts : "start", "stop"
+	receipt acts: "delivered", "read"
+
+	"origin.signature" is the digest (last 8 chars) of the original message signature
+
+Presence Event (pushed by the station to the subscribers):
+
+	+===============================+
+	|   "type" : i2s(0xCC)          |
+	|   "sn"   : 789                |
+	|   "time" : 123.456            |
+	|   "app"  : "chat.dim.status"  |
+	|   "mod"  : "presence"         |
+	|   "act"  : "online"           |
+	|                               |
+	|   "did"       : "{ID}"        |
+	|   "last_seen" : 123.456       |
+	+===============================+
+
+	presence acts: "online", "offline"
+*/
+const (
+	StatusApp = "chat.dim.status"
+
+	TypingModule = "typing"
+	TypingStart  = "start"
+	TypingStop   = "stop"
+
+	ReceiptModule    = "receipt"
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+
+	PresenceModule  = "presence"
+	PresenceOnline  = "online"
+	PresenceOffline = "offline"
+)
@@ -6,6 +6,9 @@ import (
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
 	. "github.com/dimchat/sdk-go/sdk"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
 	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
@@ -150,9 +153,46 @@ func (transmitter *MessageTransmitter) SendInstantMessage(iMsg InstantMessage, p
 		// failed
 		return nil
 	}
+	// record for matching receipts & chat history
+	transmitter.recordOutgoingMessage(iMsg, rMsg)
 	return rMsg
 }
 
+// private
+func (transmitter *MessageTransmitter) recordOutgoingMessage(iMsg InstantMessage, rMsg ReliableMessage) bool {
+	content := iMsg.Content()
+	if _, ok := content.(Command); ok {
+		// no need to track command
+		return false
+	} else if app, ok := content.(CustomizedContent); ok && app.GetString("app", "") == StatusApp {
+		// no need to track typing indicators & receipts
+		return false
+	}
+	facebook := transmitter.GetFacebook()
+	if history, ok := facebook.GetDatabase().(MessageHistoryDBI); ok {
+		// group message may be split for each member,
+		// so the chat should be the group, not the receiver
+		chat := content.Group()
+		if chat == nil {
+			chat = iMsg.Receiver()
+		}
+		history.SaveMessage(iMsg.Sender(), chat, iMsg, MessageSending)
+	}
+	db, ok := facebook.GetDatabase().(OutboxDBI)
+	if !ok {
+		// outbox not supported
+		return false
+	}
+	return db.SaveOutgoingMessage(&OutgoingMessage{
+		Sender:    iMsg.Sender(),
+		Receiver:  iMsg.Receiver(),
+		SN:        content.SN(),
+		Signature: GetSignatureDigest(rMsg.GetString("signature", "")),
+		Time:      iMsg.Time(),
+		State:     MessageSending,
+	})
+}
+
 // Override
 func (transmitter *MessageTransmitter) SendReliableMessage(rMsg ReliableMessage, priority int) bool {
 	sender := rMsg.Sender()
@@ -13,6 +13,8 @@ import (
 
 // Override
 func (db *Storage) GetIdentifier(alias string) ID {
+	db.ansLock.RLock()
+	defer db.ansLock.RUnlock()
 	return db.ansTable[alias]
 }
 
@@ -21,6 +23,8 @@ func (db *Storage) AddRecord(identifier ID, alias string) bool {
 	if len(alias) == 0 || ValueIsNil(identifier) {
 		return false
 	}
+	db.ansLock.Lock()
+	defer db.ansLock.Unlock()
 	if len(db.ansTable) == 0 {
 		panic("ANS not initialized")
 	}
@@ -32,6 +36,8 @@ func (db *Storage) AddRecord(identifier ID, alias string) bool {
 
 // Override
 func (db *Storage) RemoveRecord(alias string) bool {
+	db.ansLock.Lock()
+	defer db.ansLock.Unlock()
 	if len(alias) == 0 || db.ansTable[alias] == nil {
 		return false
 	}
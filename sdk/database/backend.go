@@ -0,0 +1,258 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// Backend defines the raw data access for Storage
+//
+// Data is addressed by path, e.g. '.dim/public/{ADDRESS}/meta.js',
+// how the path maps to the real storage is up to the implementation
+type Backend interface {
+
+	// Read returns the data stored at path, nil if not found
+	Read(path string) []byte
+
+	// Write stores data at path, overwriting the old one
+	Write(path string, data []byte) bool
+
+	// Exists checks whether data stored at path
+	Exists(path string) bool
+
+	// Remove deletes the data stored at path
+	Remove(path string) bool
+
+	// Rename moves the data stored at path 'from' to 'to', replacing the old one
+	Rename(from, to string) bool
+
+	// List returns the paths of all data stored under dir
+	List(dir string) []string
+}
+
+// BackupPath returns the path of the previous version: '{path}.bak'
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+/**
+ *  File Backend
+ *  ~~~~~~~~~~~~
+ *
+ *  Stores data as files in local directories,
+ *  files are replaced atomically, and the previous versions are kept as '.bak'
+ *  (only if they can be parsed, a damaged file won't overwrite the backup)
+ */
+type FileBackend struct {
+	//Backend
+
+	// paths of files written by this backend, known intact
+	intact map[string]bool
+	lock   sync.Mutex
+}
+
+func NewFileBackend() *FileBackend {
+	return &FileBackend{
+		intact: make(map[string]bool, 1024),
+	}
+}
+
+// Override
+func (backend *FileBackend) Read(path string) []byte {
+	return ReadBinaryFile(path)
+}
+
+// Override
+func (backend *FileBackend) Write(path string, data []byte) bool {
+	if !MakeDirs(PathDir(path)) {
+		panic(path)
+	}
+	// keep the previous version
+	if backend.isIntact(path) && !backupFile(path) {
+		LogWarning("FileBackend > failed to backup file: " + path)
+	}
+	if !WriteBinaryFile(path, data) {
+		return false
+	}
+	backend.lock.Lock()
+	backend.intact[path] = isParsable(path, data)
+	backend.lock.Unlock()
+	return true
+}
+
+// private
+func (backend *FileBackend) isIntact(path string) bool {
+	backend.lock.Lock()
+	intact, checked := backend.intact[path]
+	backend.lock.Unlock()
+	if checked {
+		// written by this backend, no need to read it again
+		return intact
+	}
+	data := ReadBinaryFile(path)
+	return data != nil && isParsable(path, data)
+}
+
+// backupFile keeps the current version of file as '{path}.bak'
+func backupFile(path string) bool {
+	// link to the current file, which will be replaced by a new one
+	if PathLink(path, BackupPath(path)) {
+		return true
+	}
+	// link not supported, copy it
+	data := ReadBinaryFile(path)
+	return data != nil && WriteBinaryFile(BackupPath(path), data)
+}
+
+// isParsable checks whether the data can be loaded by Storage
+func isParsable(path string, data []byte) bool {
+	if strings.HasSuffix(path, ".js") {
+		// JSON, or keystore data of private keys
+		return json.Valid(data)
+	} else if strings.HasSuffix(path, ".txt") {
+		return utf8.Valid(data)
+	}
+	return len(data) > 0
+}
+
+// Override
+func (backend *FileBackend) Exists(path string) bool {
+	return PathIsExist(path)
+}
+
+// Override
+func (backend *FileBackend) Remove(path string) bool {
+	backend.lock.Lock()
+	delete(backend.intact, path)
+	backend.lock.Unlock()
+	PathRemove(BackupPath(path))
+	return PathRemove(path)
+}
+
+// Override
+func (backend *FileBackend) Rename(from, to string) bool {
+	if !PathRename(from, to) {
+		return false
+	}
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	intact, checked := backend.intact[from]
+	delete(backend.intact, from)
+	if checked {
+		backend.intact[to] = intact
+	} else {
+		delete(backend.intact, to)
+	}
+	return true
+}
+
+// Override
+func (backend *FileBackend) List(dir string) []string {
+	files := PathList(dir)
+	paths := make([]string, 0, len(files))
+	for _, item := range files {
+		// skip backups & temporary files
+		if strings.HasSuffix(item, ".bak") || strings.HasSuffix(item, ".tmp") {
+			continue
+		}
+		paths = append(paths, item)
+	}
+	return paths
+}
+
+/**
+ *  Memory Backend
+ *  ~~~~~~~~~~~~~~
+ *
+ *  Keeps all data in memory, lost on exit;
+ *  for testing and ephemeral bots
+ */
+type MemoryBackend struct {
+	//Backend
+
+	records map[string][]byte
+	lock    sync.RWMutex
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		records: make(map[string][]byte, 1024),
+	}
+}
+
+// Override
+func (backend *MemoryBackend) Read(path string) []byte {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	data, exists := backend.records[path]
+	if !exists {
+		return nil
+	}
+	return cloneBytes(data)
+}
+
+// Override
+func (backend *MemoryBackend) Write(path string, data []byte) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	backend.records[path] = cloneBytes(data)
+	return true
+}
+
+// Override
+func (backend *MemoryBackend) Exists(path string) bool {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	_, exists := backend.records[path]
+	return exists
+}
+
+// Override
+func (backend *MemoryBackend) Remove(path string) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	_, exists := backend.records[path]
+	delete(backend.records, path)
+	return exists
+}
+
+// Override
+func (backend *MemoryBackend) Rename(from, to string) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	data, exists := backend.records[from]
+	if !exists {
+		return false
+	}
+	backend.records[to] = data
+	delete(backend.records, from)
+	return true
+}
+
+// Override
+func (backend *MemoryBackend) List(dir string) []string {
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+	return listPaths(backend.records, dir)
+}
+
+func listPaths(records map[string][]byte, dir string) []string {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	paths := make([]string, 0, 16)
+	for path := range records {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func cloneBytes(data []byte) []byte {
+	clone := make([]byte, len(data))
+	copy(clone, data)
+	return clone
+}
@@ -0,0 +1,388 @@
+package db
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/plugins-go/crypto"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/mkm"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/extensions"
+)
+
+// BundleVersion is the version of the account bundle format
+const BundleVersion = 1
+
+/**
+ *  Account Bundle
+ *  ~~~~~~~~~~~~~~
+ *
+ *  Packages a local user for moving to another machine,
+ *  encrypted with a passphrase in keystore format.
+ *
+ *  format: {
+ *      "type"    : "account_bundle",
+ *      "version" : 1,
+ *      "did"     : "{ID}",
+ *      "meta"    : {...},
+ *      "documents" : [...],
+ *
+ *      "identity_key"       : {...},
+ *      "communication_keys" : [...],
+ *
+ *      "contacts"      : ["{ID}", ...],
+ *      "contact_infos" : [...],
+ *      "mute"          : ["{ID}", ...],
+ *      "block"         : ["{ID}", ...],
+ *
+ *      "groups" : [
+ *          {"did": "{GID}", "meta": {...}, "documents": [...], "members": [...]},
+ *          ...
+ *      ],
+ *      "login" : {"cmd": {...}, "msg": {...}}
+ *  }
+ */
+
+// ExportAccount packages the local user into a passphrase-encrypted bundle
+//
+// Returns: nil if the passphrase is empty, or the user's meta or identity key not found
+func (db *Storage) ExportAccount(user ID, passphrase string) []byte {
+	if passphrase == "" {
+		// private keys must not be exported in plaintext
+		db.error("passphrase required to export account: " + user.String())
+		return nil
+	}
+	meta := db.GetMeta(user)
+	if meta == nil {
+		db.error("meta not found: " + user.String())
+		return nil
+	}
+	db.keyLock.Lock()
+	idKey := getIdentityKey(db, user)
+	msgKeys := getCommunicationKeys(db, user)
+	db.keyLock.Unlock()
+	if idKey == nil {
+		db.error("identity key not found: " + user.String())
+		return nil
+	}
+	keys := make([]StringKeyMap, 0, len(msgKeys))
+	for _, item := range msgKeys {
+		keys = append(keys, item.Map())
+	}
+	contacts := db.GetContacts(user)
+	bundle := NewMap()
+	bundle["type"] = "account_bundle"
+	bundle["version"] = BundleVersion
+	bundle["did"] = user.String()
+	bundle["meta"] = meta.Map()
+	bundle["documents"] = DocumentRevert(db.GetDocuments(user))
+	bundle["identity_key"] = idKey.Map()
+	bundle["communication_keys"] = keys
+	bundle["contacts"] = IDRevert(contacts)
+	bundle["contact_infos"] = RevertContactInfo(db.GetContactInfos(user))
+	bundle["mute"] = IDRevert(db.GetMuteList(user))
+	bundle["block"] = IDRevert(db.GetBlockList(user))
+	// groups
+	groups := make([]StringKeyMap, 0, 4)
+	for _, item := range contacts {
+		if !item.IsGroup() {
+			continue
+		}
+		info := NewMap()
+		info["did"] = item.String()
+		if gMeta := db.GetMeta(item); gMeta != nil {
+			info["meta"] = gMeta.Map()
+		}
+		info["documents"] = DocumentRevert(db.GetDocuments(item))
+		info["members"] = IDRevert(db.GetMembers(item))
+		groups = append(groups, info)
+	}
+	bundle["groups"] = groups
+	// login info
+	db.loginLock.Lock()
+	cmd, msg := getLoginInfo(db, user)
+	db.loginLock.Unlock()
+	if cmd != nil && msg != nil {
+		bundle["login"] = StringKeyMap{
+			"cmd": cmd.Map(),
+			"msg": msg.Map(),
+		}
+	}
+	plaintext := UTF8Encode(JSONEncodeMap(bundle))
+	return NewKeystore(passphrase).Seal(plaintext)
+}
+
+// ImportAccount validates the bundle and saves it as a local user
+//
+// Returns: ID of the imported user, nil if passphrase wrong or bundle invalid
+func (db *Storage) ImportAccount(data []byte, passphrase string) ID {
+	// bundles are exported with the default parameters, don't let the file
+	// decide how much memory & time the key derivation takes
+	if n, r, p := GetKeystoreParams(data); n != ScryptN || r != ScryptR || p != ScryptP {
+		db.error("account bundle KDF parameters not supported")
+		return nil
+	}
+	plaintext := NewKeystore(passphrase).Open(data)
+	if plaintext == nil {
+		db.error("failed to decrypt account bundle")
+		return nil
+	}
+	bundle := JSONDecodeMap(UTF8Decode(plaintext))
+	if bundle == nil || bundle["type"] != "account_bundle" {
+		db.error("account bundle error")
+		return nil
+	} else if ConvertInt(bundle["version"], 0) != BundleVersion {
+		db.error("account bundle version not supported")
+		return nil
+	}
+	account := parseAccountBundle(bundle)
+	if account == nil || !account.validate(db) {
+		return nil
+	}
+	if !account.save(db) {
+		return nil
+	}
+	return account.user
+}
+
+type groupBundle struct {
+	group     ID
+	meta      Meta
+	documents []Document
+	members   []ID
+}
+
+type accountBundle struct {
+	user      ID
+	meta      Meta
+	documents []Document
+
+	identityKey       PrivateKey
+	communicationKeys []PrivateKey
+
+	contacts     []ID
+	contactInfos []*ContactInfo
+	muteList     []ID
+	blockList    []ID
+
+	groups []*groupBundle
+
+	loginCommand LoginCommand
+	loginMessage ReliableMessage
+}
+
+func parseAccountBundle(bundle StringKeyMap) *accountBundle {
+	account := &accountBundle{
+		user:         ParseID(bundle["did"]),
+		meta:         ParseMeta(bundle["meta"]),
+		documents:    DocumentConvert(bundle["documents"]),
+		identityKey:  ParsePrivateKey(bundle["identity_key"]),
+		contacts:     IDConvert(bundle["contacts"]),
+		contactInfos: ConvertContactInfo(fetchMapList(bundle["contact_infos"])),
+		muteList:     IDConvert(bundle["mute"]),
+		blockList:    IDConvert(bundle["block"]),
+	}
+	if account.user == nil || account.meta == nil || account.identityKey == nil {
+		return nil
+	}
+	if keys, ok := bundle["communication_keys"].([]interface{}); ok {
+		for _, item := range keys {
+			key := ParsePrivateKey(item)
+			if key == nil {
+				return nil
+			}
+			account.communicationKeys = append(account.communicationKeys, key)
+		}
+	}
+	for _, item := range fetchMapList(bundle["groups"]) {
+		group := ParseID(item["did"])
+		if group == nil {
+			return nil
+		}
+		account.groups = append(account.groups, &groupBundle{
+			group:     group,
+			meta:      ParseMeta(item["meta"]),
+			documents: DocumentConvert(item["documents"]),
+			members:   IDConvert(item["members"]),
+		})
+	}
+	if login, ok := bundle["login"].(StringKeyMap); ok {
+		account.loginCommand, _ = ParseContent(login["cmd"]).(LoginCommand)
+		account.loginMessage = ParseReliableMessage(login["msg"])
+	}
+	return account
+}
+
+// private
+func (account *accountBundle) validate(db *Storage) bool {
+	user := account.user
+	// 1. meta matches ID
+	if !user.IsUser() || !MetaMatchID(user, account.meta) {
+		db.error("meta not match: " + user.String())
+		return false
+	}
+	// 2. documents signed by meta key
+	metaKey := account.meta.PublicKey()
+	for _, doc := range account.documents {
+		if did := GetDocumentID(doc); did == nil || !did.Equal(user) || !doc.Verify(metaKey) {
+			db.error("document not valid: " + user.String())
+			return false
+		}
+	}
+	// 3. identity key paired with meta key
+	if !MatchSignKey(account.identityKey, metaKey) {
+		db.error("identity key not match: " + user.String())
+		return false
+	}
+	// 4. every communication key is a pair of decrypt & encrypt keys,
+	//    and the current one paired with visa key
+	for _, item := range account.communicationKeys {
+		decKey, ok := item.(DecryptKey)
+		if !ok {
+			db.error("communication key error: " + user.String())
+			return false
+		}
+		encKey, ok := item.PublicKey().(EncryptKey)
+		if !ok || !MatchEncryptKey(encKey, decKey) {
+			db.error("communication key not paired: " + user.String())
+			return false
+		}
+	}
+	if visa := GetLastVisa(account.documents); visa != nil && len(account.communicationKeys) > 0 {
+		decKey := account.communicationKeys[0].(DecryptKey)
+		if visaKey := visa.PublicKey(); visaKey != nil && !MatchEncryptKey(visaKey, decKey) {
+			db.error("communication key not match: " + user.String())
+			return false
+		}
+	}
+	// 5. group meta matches group ID, and members are users
+	for _, item := range account.groups {
+		if !item.group.IsGroup() {
+			db.error("group ID error: " + item.group.String())
+			return false
+		} else if item.meta != nil && !MetaMatchID(item.group, item.meta) {
+			db.error("group meta not match: " + item.group.String())
+			return false
+		}
+		for _, member := range item.members {
+			if !member.IsUser() || member.IsBroadcast() {
+				db.error("group member error: " + member.String() + ", group: " + item.group.String())
+				return false
+			}
+		}
+	}
+	// 6. login command & message sent and signed by the user
+	if cmd := account.loginCommand; cmd != nil && !user.Equal(cmd.ID()) {
+		db.error("login command not match: " + user.String())
+		return false
+	}
+	if msg := account.loginMessage; msg != nil {
+		if !msg.Sender().Equal(user) || !account.verifyMessage(msg) {
+			db.error("login message not match: " + user.String())
+			return false
+		}
+	}
+	return true
+}
+
+// verifyMessage checks the signature with the user's meta key, visa key,
+// or any of the communication keys (the message may be signed by a retired one)
+func (account *accountBundle) verifyMessage(rMsg ReliableMessage) bool {
+	data := rMsg.Data()
+	signature := rMsg.Signature()
+	if data == nil || data.IsEmpty() || signature == nil || signature.IsEmpty() {
+		return false
+	}
+	keys := make([]VerifyKey, 0, len(account.communicationKeys)+2)
+	keys = append(keys, account.meta.PublicKey())
+	if visa := GetLastVisa(account.documents); visa != nil {
+		if visaKey, ok := visa.PublicKey().(VerifyKey); ok {
+			keys = append(keys, visaKey)
+		}
+	}
+	for _, item := range account.communicationKeys {
+		keys = append(keys, item.PublicKey())
+	}
+	for _, key := range keys {
+		if key != nil && key.Verify(data.Bytes(), signature.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// private
+func (account *accountBundle) save(db *Storage) bool {
+	user := account.user
+	// meta & documents
+	if !db.SaveMeta(account.meta, user) {
+		return false
+	}
+	for _, doc := range account.documents {
+		db.SaveDocument(doc, user)
+	}
+	// private keys
+	if old := db.GetPrivateKeyForVisaSignature(user); old == nil {
+		if !db.SavePrivateKey(account.identityKey, META_KEY, user) {
+			return false
+		}
+	}
+	// saving from the oldest one, so the current key will be in the front
+	for index := len(account.communicationKeys) - 1; index >= 0; index-- {
+		db.SavePrivateKey(account.communicationKeys[index], VISA_KEY, user)
+	}
+	// contacts
+	for _, item := range account.contacts {
+		db.AddContact(item, user)
+	}
+	if len(account.contactInfos) > 0 {
+		db.SaveContactInfos(account.contactInfos, user)
+	}
+	if len(account.muteList) > 0 {
+		db.SaveMuteList(account.muteList, user)
+	}
+	if len(account.blockList) > 0 {
+		db.SaveBlockList(account.blockList, user)
+	}
+	// groups
+	for _, item := range account.groups {
+		if item.meta == nil || !db.SaveMeta(item.meta, item.group) {
+			continue
+		}
+		groupKey := item.meta.PublicKey()
+		for _, doc := range item.documents {
+			if doc.Verify(groupKey) {
+				db.SaveDocument(doc, item.group)
+			} else {
+				db.warning("skip unverified group document: " + item.group.String())
+			}
+		}
+		if len(item.members) > 0 {
+			db.SaveMembers(item.members, item.group)
+		}
+	}
+	// login info
+	if account.loginCommand != nil && account.loginMessage != nil {
+		db.SaveLoginCommandMessage(user, account.loginCommand, account.loginMessage)
+	}
+	db.AddUser(user)
+	db.log("Account imported: " + user.String())
+	return true
+}
+
+func fetchMapList(array interface{}) []StringKeyMap {
+	list, ok := array.([]interface{})
+	if !ok {
+		return nil
+	}
+	records := make([]StringKeyMap, 0, len(list))
+	for _, item := range list {
+		if dict, ok := item.(StringKeyMap); ok {
+			records = append(records, dict)
+		}
+	}
+	return records
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+//-------- CheckerTable
+
+// Override
+func (db *Storage) GetLastDocumentTimes() map[string]Time {
+	db.timesLock.Lock()
+	defer db.timesLock.Unlock()
+	table := db.documentTimeTable
+	if table == nil {
+		table = loadTimes(db, documentTimeFile)
+		db.documentTimeTable = table
+	}
+	return copyTimes(table)
+}
+
+// Override
+func (db *Storage) SaveLastDocumentTime(did ID, lastTime Time) bool {
+	db.timesLock.Lock()
+	defer db.timesLock.Unlock()
+	if TimeIsNil(lastTime) {
+		return false
+	}
+	if db.documentTimeTable != nil {
+		db.documentTimeTable[did.String()] = lastTime
+	}
+	return saveTime(db, timePath(db, did, documentTimeFile), did, lastTime)
+}
+
+// Override
+func (db *Storage) GetLastGroupHistoryTimes() map[string]Time {
+	db.timesLock.Lock()
+	defer db.timesLock.Unlock()
+	table := db.historyTimeTable
+	if table == nil {
+		table = loadTimes(db, historyTimeFile)
+		db.historyTimeTable = table
+	}
+	return copyTimes(table)
+}
+
+// Override
+func (db *Storage) SaveLastGroupHistoryTime(gid ID, lastTime Time) bool {
+	db.timesLock.Lock()
+	defer db.timesLock.Unlock()
+	if TimeIsNil(lastTime) {
+		return false
+	}
+	if db.historyTimeTable != nil {
+		db.historyTimeTable[gid.String()] = lastTime
+	}
+	return saveTime(db, timePath(db, gid, historyTimeFile), gid, lastTime)
+}
+
+/**
+ *  Recent Times for Entity Checker
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  one record for each entity, so a new time won't rewrite the others
+ *
+ *  file path: '.dim/protected/{ADDRESS}/document_time.js'
+ *  file path: '.dim/protected/{ADDRESS}/history_time.js'
+ */
+
+const (
+	documentTimeFile = "document_time.js"
+	historyTimeFile  = "history_time.js"
+)
+
+func timePath(db *Storage, did ID, filename string) string {
+	return PathJoin(db.Root(), "protected", did.Address().String(), filename)
+}
+
+func loadTimes(db *Storage, filename string) map[string]Time {
+	dir := PathJoin(db.Root(), "protected")
+	db.log("Loading recent times: " + filename)
+	paths := db.backend.List(dir)
+	table := make(map[string]Time, len(paths))
+	for _, path := range paths {
+		if PathBase(path) != filename {
+			continue
+		}
+		info := db.readMap(path)
+		did := ParseID(info["did"])
+		when := ConvertTime(info["time"], nil)
+		if did == nil || TimeIsNil(when) {
+			db.warning("recent time error: " + path)
+			continue
+		}
+		table[did.String()] = when
+	}
+	return table
+}
+
+func saveTime(db *Storage, path string, did ID, lastTime Time) bool {
+	info := NewMap()
+	info["did"] = did.String()
+	info["time"] = TimeToFloat64(lastTime)
+	return db.writeMap(path, info)
+}
+
+func copyTimes(table map[string]Time) map[string]Time {
+	times := make(map[string]Time, len(table))
+	for key, value := range table {
+		times[key] = value
+	}
+	return times
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// Storage backend names
+const (
+	BackendFile   = "file"   // JSON & text files under root directory
+	BackendMemory = "memory" // in memory only, lost on exit
+	BackendKV     = "kv"     // embedded key-value store in a single file
+)
+
+// StorageConfig selects the backend for Storage
+type StorageConfig struct {
+	// backend name, default is "file"
+	Backend string
+
+	// root directory for database
+	Root string
+
+	// data file for "kv" backend, default is '{root}/dim.kv'
+	Path string
+
+	// passphrase for private keys, empty to save them in plaintext
+	Password string
+}
+
+// NewStorageWithConfig creates storage with the backend selected by config
+//
+// Returns: nil for unknown backend, or backend not opened
+func NewStorageWithConfig(config *StorageConfig) *Storage {
+	var backend Backend
+	switch config.Backend {
+	case BackendMemory:
+		backend = NewMemoryBackend()
+	case BackendKV:
+		path := config.Path
+		if path == "" {
+			path = PathJoin(config.Root, "dim.kv")
+		}
+		kv := NewKVBackend(path)
+		if kv == nil {
+			return nil
+		}
+		backend = kv
+	case BackendFile, "":
+		backend = NewFileBackend()
+	default:
+		LogError("unknown storage backend: " + config.Backend)
+		return nil
+	}
+	db := NewStorageWithBackend(config.Root, backend)
+	if config.Password != "" {
+		db.SetPassword(config.Password)
+	}
+	return db
+}
+
+// IsBackendSupported checks whether the backend name is known
+func IsBackendSupported(name string) bool {
+	switch name {
+	case BackendFile, BackendMemory, BackendKV, "":
+		return true
+	}
+	return false
+}
@@ -11,28 +11,31 @@ import (
 
 // Override
 func (db *Storage) GetContacts(user ID) []ID {
-	arr := db.contactTable[user.String()]
-	if arr == nil {
-		arr = loadContacts(db, user)
-		db.contactTable[user.String()] = arr
-	}
-	return arr
+	db.contactLock.Lock()
+	defer db.contactLock.Unlock()
+	return db.getContacts(user)
 }
 
 func (db *Storage) AddContact(contact ID, user ID) bool {
-	arr := db.GetContacts(user)
+	db.contactLock.Lock()
+	defer db.contactLock.Unlock()
+	arr := db.getContacts(user)
 	for _, item := range arr {
 		if contact.Equal(item) {
 			// duplicated
 			return false
 		}
 	}
-	arr = append(arr, contact)
-	return db.SaveContacts(arr, user)
+	contacts := make([]ID, 0, len(arr)+1)
+	contacts = append(contacts, arr...)
+	contacts = append(contacts, contact)
+	return db.saveContacts(contacts, user)
 }
 
 func (db *Storage) RemoveContact(contact ID, user ID) bool {
-	arr := db.GetContacts(user)
+	db.contactLock.Lock()
+	defer db.contactLock.Unlock()
+	arr := db.getContacts(user)
 	var pos = -1
 	for index, id := range arr {
 		if contact.Equal(id) {
@@ -44,12 +47,31 @@ func (db *Storage) RemoveContact(contact ID, user ID) bool {
 		// contact ID not found
 		return false
 	}
-	arr = append(arr[:pos], arr[pos+1:]...)
-	return db.SaveContacts(arr, user)
+	contacts := make([]ID, 0, len(arr)-1)
+	contacts = append(contacts, arr[:pos]...)
+	contacts = append(contacts, arr[pos+1:]...)
+	return db.saveContacts(contacts, user)
 }
 
 // Override
 func (db *Storage) SaveContacts(contacts []ID, user ID) bool {
+	db.contactLock.Lock()
+	defer db.contactLock.Unlock()
+	return db.saveContacts(contacts, user)
+}
+
+// private
+func (db *Storage) getContacts(user ID) []ID {
+	arr := db.contactTable[user.String()]
+	if arr == nil {
+		arr = loadContacts(db, user)
+		db.contactTable[user.String()] = arr
+	}
+	return arr
+}
+
+// private
+func (db *Storage) saveContacts(contacts []ID, user ID) bool {
 	db.contactTable[user.String()] = contacts
 	return saveContacts(db, user, contacts)
 }
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"strings"
+
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+//-------- ContactInfoTable
+
+// Override
+func (db *Storage) GetContactInfos(user ID) []*ContactInfo {
+	db.contactInfoLock.Lock()
+	defer db.contactInfoLock.Unlock()
+	arr := db.contactInfoTable[user.String()]
+	if arr == nil {
+		arr = loadContactInfos(db, user)
+		db.contactInfoTable[user.String()] = arr
+	}
+	return arr
+}
+
+// Override
+func (db *Storage) SaveContactInfos(infos []*ContactInfo, user ID) bool {
+	db.contactInfoLock.Lock()
+	defer db.contactInfoLock.Unlock()
+	db.contactInfoTable[user.String()] = infos
+	return saveContactInfos(db, user, infos)
+}
+
+//-------- MuteTable
+
+// Override
+func (db *Storage) GetMuteList(user ID) []ID {
+	db.muteLock.Lock()
+	defer db.muteLock.Unlock()
+	arr := db.muteTable[user.String()]
+	if arr == nil {
+		arr = loadIDList(db, muteListPath(db, user))
+		db.muteTable[user.String()] = arr
+	}
+	return arr
+}
+
+// Override
+func (db *Storage) SaveMuteList(muted []ID, user ID) bool {
+	db.muteLock.Lock()
+	defer db.muteLock.Unlock()
+	db.muteTable[user.String()] = muted
+	db.log("Saving mute-list for user: " + user.String())
+	return saveIDList(db, muteListPath(db, user), muted)
+}
+
+//-------- BlockTable
+
+// Override
+func (db *Storage) GetBlockList(user ID) []ID {
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+	arr := db.blockTable[user.String()]
+	if arr == nil {
+		arr = loadIDList(db, blockListPath(db, user))
+		db.blockTable[user.String()] = arr
+	}
+	return arr
+}
+
+// Override
+func (db *Storage) SaveBlockList(blocked []ID, user ID) bool {
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+	db.blockTable[user.String()] = blocked
+	db.log("Saving block-list for user: " + user.String())
+	return saveIDList(db, blockListPath(db, user), blocked)
+}
+
+/**
+ *  Contact Settings for User
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  file path: '.dim/protected/{ADDRESS}/contact_infos.js'
+ *  file path: '.dim/protected/{ADDRESS}/mute.txt'
+ *  file path: '.dim/protected/{ADDRESS}/block.txt'
+ */
+
+func contactInfosPath(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "contact_infos.js")
+}
+
+func muteListPath(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "mute.txt")
+}
+
+func blockListPath(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "block.txt")
+}
+
+func loadContactInfos(db *Storage, user ID) []*ContactInfo {
+	path := contactInfosPath(db, user)
+	db.log("Loading contact infos for user: " + user.String())
+	array := db.readList(path)
+	infos := make([]StringKeyMap, 0, len(array))
+	for _, item := range array {
+		if dict, ok := item.(StringKeyMap); ok {
+			infos = append(infos, dict)
+		}
+	}
+	return ConvertContactInfo(infos)
+}
+
+func saveContactInfos(db *Storage, user ID, infos []*ContactInfo) bool {
+	path := contactInfosPath(db, user)
+	db.log("Saving contact infos for user: " + user.String())
+	return db.writeList(path, RevertContactInfo(infos))
+}
+
+func loadIDList(db *Storage, path string) []ID {
+	text := db.readText(path)
+	lines := strings.Split(text, "\n")
+	array := make([]ID, 0, len(lines))
+	for _, rec := range lines {
+		id := ParseID(rec)
+		if id != nil {
+			array = append(array, id)
+		}
+	}
+	return array
+}
+
+func saveIDList(db *Storage, path string, array []ID) bool {
+	text := ""
+	lines := IDRevert(array)
+	for _, rec := range lines {
+		text = text + rec + "\n"
+	}
+	return db.writeText(path, text)
+}
@@ -16,6 +16,8 @@ func (db *Storage) SaveDocument(doc Document, entity ID) bool {
 	// TODO: check old documents
 	array := make([]Document, 1)
 	array[0] = doc
+	db.documentLock.Lock()
+	defer db.documentLock.Unlock()
 	// 2. cache it
 	db.documentTable[entity.String()] = array
 	// 3. save into local storage
@@ -25,7 +27,14 @@ func (db *Storage) SaveDocument(doc Document, entity ID) bool {
 // Override
 func (db *Storage) GetDocuments(entity ID) []Document {
 	// 1. try from memory cache
+	db.documentLock.RLock()
 	docs := db.documentTable[entity.String()]
+	db.documentLock.RUnlock()
+	if docs == nil {
+		db.documentLock.Lock()
+		defer db.documentLock.Unlock()
+		docs = db.documentTable[entity.String()]
+	}
 	if docs == nil {
 		// 2. try from local storage
 		docs = loadDocuments(db, entity)
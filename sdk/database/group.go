@@ -37,7 +37,14 @@ func (db *Storage) SaveAdministrators(admins []ID, group ID) bool {
 
 // Override
 func (db *Storage) GetMembers(group ID) []ID {
+	db.memberLock.RLock()
 	arr := db.memberTable[group.String()]
+	db.memberLock.RUnlock()
+	if arr == nil {
+		db.memberLock.Lock()
+		defer db.memberLock.Unlock()
+		arr = db.memberTable[group.String()]
+	}
 	if arr == nil {
 		arr = loadMembers(db, group)
 		db.memberTable[group.String()] = arr
@@ -47,6 +54,8 @@ func (db *Storage) GetMembers(group ID) []ID {
 
 // Override
 func (db *Storage) SaveMembers(members []ID, group ID) bool {
+	db.memberLock.Lock()
+	defer db.memberLock.Unlock()
 	db.memberTable[group.String()] = members
 	return saveMembers(db, group, members)
 }
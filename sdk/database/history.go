@@ -0,0 +1,337 @@
+package db
+
+import (
+	"strconv"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+//-------- MessageHistoryTable
+
+// Override
+func (db *Storage) GetConversations(user ID) []ID {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	return db.getConversations(user)
+}
+
+// private
+func (db *Storage) getConversations(user ID) []ID {
+	chats := db.conversationTable[user.String()]
+	if chats == nil {
+		chats = loadIDList(db, conversationsPath(db, user))
+		db.conversationTable[user.String()] = chats
+	}
+	return chats
+}
+
+// Override
+func (db *Storage) SaveMessage(user, chat ID, iMsg InstantMessage, state int) bool {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	messages := db.getHistoryMessages(user, chat)
+	sender := iMsg.Sender()
+	sn := iMsg.Content().SN()
+	// check duplicated
+	for index, item := range messages {
+		if item.Is(sender, sn) {
+			item.Message = iMsg
+			if item.State < state {
+				item.State = state
+			}
+			db.indexMessage(user, chat, item)
+			return saveHistoryPage(db, user, chat, messages, index)
+		}
+	}
+	// insert in time order
+	msg := &HistoryMessage{
+		Message: iMsg,
+		State:   state,
+	}
+	pos := len(messages)
+	for pos > 0 && TimeIsAfter(iMsg.Time(), messages[pos-1].Time()) {
+		pos--
+	}
+	messages = append(messages, nil)
+	copy(messages[pos+1:], messages[pos:])
+	messages[pos] = msg
+	db.historyTable[historyKey(user, chat)] = messages
+	db.touchConversation(user, chat)
+	db.indexMessage(user, chat, msg)
+	// the pages after the new message are changed
+	return saveHistory(db, user, chat, messages, pos)
+}
+
+// Override
+func (db *Storage) UpdateMessageState(user, chat, sender ID, sn SerialNumberType, state int) bool {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	messages := db.getHistoryMessages(user, chat)
+	index := findHistoryMessage(messages, sender, sn)
+	if index < 0 || messages[index].State >= state {
+		// not found, or state not changed
+		return false
+	}
+	messages[index].State = state
+	return saveHistoryPage(db, user, chat, messages, index)
+}
+
+// Override
+func (db *Storage) GetMessage(user, chat, sender ID, sn SerialNumberType) *HistoryMessage {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	msg := db.getMessage(user, chat, sender, sn)
+	if msg == nil {
+		return nil
+	}
+	clone := *msg
+	return &clone
+}
+
+// private
+func (db *Storage) getMessage(user, chat, sender ID, sn SerialNumberType) *HistoryMessage {
+	messages := db.getHistoryMessages(user, chat)
+	index := findHistoryMessage(messages, sender, sn)
+	if index < 0 {
+		return nil
+	}
+	return messages[index]
+}
+
+// Override
+func (db *Storage) GetMessagesBeforeTime(user, chat ID, before Time, limit int) []*HistoryMessage {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	messages := db.getHistoryMessages(user, chat)
+	end := len(messages)
+	if !TimeIsNil(before) {
+		for end > 0 && !TimeIsAfter(messages[end-1].Time(), before) {
+			end--
+		}
+	}
+	return pageMessages(messages, end, limit)
+}
+
+// Override
+func (db *Storage) GetMessagesBeforeSN(user, chat ID, sn SerialNumberType, limit int) []*HistoryMessage {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	messages := db.getHistoryMessages(user, chat)
+	end := len(messages)
+	if sn > 0 {
+		for end > 0 && messages[end-1].SN() != sn {
+			end--
+		}
+		if end == 0 {
+			// message not found
+			return nil
+		}
+		// exclude the anchor message
+		end--
+	}
+	return pageMessages(messages, end, limit)
+}
+
+// Override
+func (db *Storage) SearchMessages(user, chat ID, keyword string, limit int) []*HistoryMessage {
+	return db.QueryMessages(user, &MessageQuery{
+		Keyword: keyword,
+		Chat:    chat,
+		Limit:   limit,
+	})
+}
+
+// Override
+func (db *Storage) RemoveMessage(user, chat, sender ID, sn SerialNumberType) bool {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	messages := db.getHistoryMessages(user, chat)
+	index := findHistoryMessage(messages, sender, sn)
+	if index < 0 {
+		// message not found
+		return false
+	}
+	messages = removeHistoryMessage(messages, index)
+	db.historyTable[historyKey(user, chat)] = messages
+	db.unindexMessage(user, chat, sender, sn)
+	// the pages after the removed message are changed
+	return saveHistory(db, user, chat, messages, index)
+}
+
+// Override
+func (db *Storage) RemoveConversation(user, chat ID) bool {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	delete(db.historyTable, historyKey(user, chat))
+	db.unindexConversation(user, chat)
+	chats := db.getConversations(user)
+	for index, item := range chats {
+		if item.Equal(chat) {
+			chats = append(append([]ID{}, chats[:index]...), chats[index+1:]...)
+			db.conversationTable[user.String()] = chats
+			saveIDList(db, conversationsPath(db, user), chats)
+			break
+		}
+	}
+	db.log("Removing conversation: " + chat.String() + ", user: " + user.String())
+	return saveHistory(db, user, chat, nil, 0)
+}
+
+// private
+func (db *Storage) getHistoryMessages(user, chat ID) []*HistoryMessage {
+	key := historyKey(user, chat)
+	messages := db.historyTable[key]
+	if messages == nil {
+		messages = loadHistory(db, user, chat)
+		db.historyTable[key] = messages
+	}
+	return messages
+}
+
+// private
+func (db *Storage) touchConversation(user, chat ID) {
+	chats := db.getConversations(user)
+	if len(chats) > 0 && chats[0].Equal(chat) {
+		// already the latest one
+		return
+	}
+	list := make([]ID, 0, len(chats)+1)
+	list = append(list, chat)
+	for _, item := range chats {
+		if !item.Equal(chat) {
+			list = append(list, item)
+		}
+	}
+	db.conversationTable[user.String()] = list
+	saveIDList(db, conversationsPath(db, user), list)
+}
+
+func historyKey(user, chat ID) string {
+	return user.String() + "|" + chat.String()
+}
+
+// pageMessages returns at most 'limit' messages before index 'end'
+func pageMessages(messages []*HistoryMessage, end, limit int) []*HistoryMessage {
+	start := 0
+	if limit > 0 && end > limit {
+		start = end - limit
+	}
+	page := make([]*HistoryMessage, 0, end-start)
+	for _, item := range messages[start:end] {
+		clone := *item
+		page = append(page, &clone)
+	}
+	return page
+}
+
+// findHistoryMessage returns the position of the message, -1 if not found
+func findHistoryMessage(messages []*HistoryMessage, sender ID, sn SerialNumberType) int {
+	// search from the newest one
+	for index := len(messages) - 1; index >= 0; index-- {
+		if messages[index].Is(sender, sn) {
+			return index
+		}
+	}
+	return -1
+}
+
+func removeHistoryMessage(messages []*HistoryMessage, index int) []*HistoryMessage {
+	array := make([]*HistoryMessage, 0, len(messages)-1)
+	array = append(array, messages[:index]...)
+	return append(array, messages[index+1:]...)
+}
+
+/**
+ *  Message History for User
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  file path: '.dim/protected/{ADDRESS}/conversations.txt'
+ *  file path: '.dim/protected/{ADDRESS}/messages/{CHAT_ADDRESS}/{PAGE}.js'
+ *
+ *  messages are kept in time order and split into pages,
+ *  so a new message only rewrites the last page
+ */
+
+// HistoryPageSize is the number of messages in each page file
+const HistoryPageSize = 128
+
+func conversationsPath(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "conversations.txt")
+}
+
+func historyPagePath(db *Storage, user, chat ID, page int) string {
+	filename := strconv.Itoa(page) + ".js"
+	return PathJoin(db.Root(), "protected", user.Address().String(), "messages", chat.Address().String(), filename)
+}
+
+// history file of old version: '.dim/protected/{ADDRESS}/messages/{CHAT_ADDRESS}.js'
+func legacyHistoryPath(db *Storage, user, chat ID) string {
+	filename := chat.Address().String() + ".js"
+	return PathJoin(db.Root(), "protected", user.Address().String(), "messages", filename)
+}
+
+func loadHistory(db *Storage, user, chat ID) []*HistoryMessage {
+	db.log("Loading messages: " + chat.String() + ", user: " + user.String())
+	if path := legacyHistoryPath(db, user, chat); db.isExist(path) {
+		// split the old file into pages
+		messages := ConvertHistoryMessages(readMapList(db, path))
+		if saveHistory(db, user, chat, messages, 0) {
+			db.remove(path)
+		}
+		return messages
+	}
+	messages := make([]*HistoryMessage, 0, HistoryPageSize)
+	aligned := true
+	for page := 0; ; page++ {
+		path := historyPagePath(db, user, chat, page)
+		if !db.isExist(path) {
+			break
+		}
+		array := readMapList(db, path)
+		records := ConvertHistoryMessages(array)
+		if len(records) != len(array) || (len(records) != HistoryPageSize && db.isExist(historyPagePath(db, user, chat, page+1))) {
+			// broken records dropped, or page not full
+			aligned = false
+		}
+		messages = append(messages, records...)
+	}
+	if !aligned {
+		// rewrite all pages, so the positions will match the pages again
+		db.warning("realigning message pages: " + chat.String() + ", user: " + user.String())
+		saveHistory(db, user, chat, messages, 0)
+	}
+	return messages
+}
+
+// saveHistory writes the pages from the one containing position 'from' to the last one,
+// and removes the pages beyond
+func saveHistory(db *Storage, user, chat ID, messages []*HistoryMessage, from int) bool {
+	ok := true
+	page := from / HistoryPageSize
+	for ; page*HistoryPageSize < len(messages); page++ {
+		if !saveHistoryPage(db, user, chat, messages, page*HistoryPageSize) {
+			ok = false
+		}
+	}
+	for path := historyPagePath(db, user, chat, page); db.isExist(path); path = historyPagePath(db, user, chat, page) {
+		db.remove(path)
+		page++
+	}
+	return ok
+}
+
+// saveHistoryPage writes the page containing the message at 'index'
+func saveHistoryPage(db *Storage, user, chat ID, messages []*HistoryMessage, index int) bool {
+	page := index / HistoryPageSize
+	start := page * HistoryPageSize
+	end := start + HistoryPageSize
+	if end > len(messages) {
+		end = len(messages)
+	}
+	path := historyPagePath(db, user, chat, page)
+	return db.writeList(path, RevertHistoryMessages(messages[start:end]))
+}
@@ -0,0 +1,415 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+//-------- MessageIndexTable
+
+// Override
+func (db *Storage) QueryMessages(user ID, query *MessageQuery) []*HistoryMessage {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	index := db.getMessageIndex(user)
+	entries := index.search(tokenize(query.Keyword))
+	results := make([]*HistoryMessage, 0, len(entries))
+	for _, item := range entries {
+		if query.Chat != nil && !query.Chat.Equal(item.chat) {
+			continue
+		} else if !TimeIsNil(query.Start) && TimeIsBefore(query.Start, item.time) {
+			continue
+		} else if !TimeIsNil(query.End) && TimeIsAfter(query.End, item.time) {
+			continue
+		}
+		msg := db.getMessage(user, item.chat, item.sender, item.sn)
+		if msg == nil {
+			// should not happen
+			continue
+		}
+		clone := *msg
+		results = append(results, &clone)
+		if query.Limit > 0 && len(results) >= query.Limit {
+			break
+		}
+	}
+	return results
+}
+
+// Override
+func (db *Storage) RebuildIndex(user ID) bool {
+	db.historyLock.Lock()
+	defer db.historyLock.Unlock()
+	return db.rebuildIndex(user)
+}
+
+// private
+func (db *Storage) rebuildIndex(user ID) bool {
+	index := newMessageIndex()
+	for _, chat := range db.getConversations(user) {
+		for _, msg := range db.getHistoryMessages(user, chat) {
+			index.add(newIndexEntry(chat, msg))
+		}
+	}
+	db.indexTable[user.String()] = index
+	db.log("Rebuilding message index for user: " + user.String())
+	// drop the index file of old version
+	if path := legacyIndexPath(db, user); db.isExist(path) {
+		db.remove(path)
+	}
+	ok := true
+	for _, chat := range db.getConversations(user) {
+		if !saveIndexSegment(db, user, chat, index) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// private
+func (db *Storage) getMessageIndex(user ID) *messageIndex {
+	index := db.indexTable[user.String()]
+	if index == nil {
+		index = loadMessageIndex(db, user)
+		if index == nil {
+			db.rebuildIndex(user)
+			index = db.indexTable[user.String()]
+		} else {
+			db.indexTable[user.String()] = index
+			db.backfillIndex(user, index)
+		}
+	}
+	return index
+}
+
+// backfillIndex indexes the conversations without segment,
+// e.g.: history saved before the index was built
+func (db *Storage) backfillIndex(user ID, index *messageIndex) {
+	for _, chat := range db.getConversations(user) {
+		if db.isExist(indexSegmentPath(db, user, chat)) {
+			// already indexed
+			continue
+		}
+		db.log("Indexing conversation: " + chat.String() + ", user: " + user.String())
+		for _, msg := range db.getHistoryMessages(user, chat) {
+			index.add(newIndexEntry(chat, msg))
+		}
+		saveIndexSegment(db, user, chat, index)
+	}
+}
+
+// private
+func (db *Storage) indexMessage(user, chat ID, msg *HistoryMessage) bool {
+	index := db.getMessageIndex(user)
+	index.add(newIndexEntry(chat, msg))
+	return saveIndexSegment(db, user, chat, index)
+}
+
+// private
+func (db *Storage) unindexMessage(user, chat, sender ID, sn SerialNumberType) bool {
+	index := db.getMessageIndex(user)
+	if !index.remove(indexKey(chat, sender, sn)) {
+		return false
+	}
+	return saveIndexSegment(db, user, chat, index)
+}
+
+// private
+func (db *Storage) unindexConversation(user, chat ID) bool {
+	index := db.getMessageIndex(user)
+	index.removeChat(chat)
+	path := indexSegmentPath(db, user, chat)
+	if !db.isExist(path) {
+		return false
+	}
+	return db.remove(path)
+}
+
+/**
+ *  Inverted Index
+ *  ~~~~~~~~~~~~~~
+ *
+ *  Maps each term to the messages containing it,
+ *  messages are keyed by '{CHAT}#{SENDER}#{SN}';
+ *  terms are also kept in order for prefix lookups
+ */
+
+type indexEntry struct {
+	chat   ID
+	sender ID
+	sn     SerialNumberType
+	time   Time
+	tokens []string
+}
+
+func newIndexEntry(chat ID, msg *HistoryMessage) *indexEntry {
+	return &indexEntry{
+		chat:   chat,
+		sender: msg.Sender(),
+		sn:     msg.SN(),
+		time:   msg.Time(),
+		tokens: tokenize(msg.Text()),
+	}
+}
+
+func indexKey(chat, sender ID, sn SerialNumberType) string {
+	return chat.String() + "#" + sender.String() + "#" + strconv.FormatUint(uint64(sn), 10)
+}
+
+type messageIndex struct {
+	entries  map[string]*indexEntry    // key -> entry
+	postings map[string]map[string]any // term -> keys
+	chats    map[string]map[string]any // chat -> keys
+
+	// all terms in order, nil when changed
+	terms []string
+}
+
+func newMessageIndex() *messageIndex {
+	return &messageIndex{
+		entries:  make(map[string]*indexEntry, 1024),
+		postings: make(map[string]map[string]any, 1024),
+		chats:    make(map[string]map[string]any, 16),
+		terms:    nil,
+	}
+}
+
+func (index *messageIndex) add(entry *indexEntry) {
+	key := indexKey(entry.chat, entry.sender, entry.sn)
+	index.remove(key)
+	if len(entry.tokens) == 0 {
+		// nothing to search
+		return
+	}
+	index.entries[key] = entry
+	for _, term := range entry.tokens {
+		keys := index.postings[term]
+		if keys == nil {
+			keys = make(map[string]any, 4)
+			index.postings[term] = keys
+			index.terms = nil
+		}
+		keys[key] = nil
+	}
+	keys := index.chats[entry.chat.String()]
+	if keys == nil {
+		keys = make(map[string]any, 64)
+		index.chats[entry.chat.String()] = keys
+	}
+	keys[key] = nil
+}
+
+func (index *messageIndex) remove(key string) bool {
+	entry := index.entries[key]
+	if entry == nil {
+		return false
+	}
+	delete(index.entries, key)
+	for _, term := range entry.tokens {
+		keys := index.postings[term]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(index.postings, term)
+			index.terms = nil
+		}
+	}
+	keys := index.chats[entry.chat.String()]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(index.chats, entry.chat.String())
+	}
+	return true
+}
+
+func (index *messageIndex) removeChat(chat ID) int {
+	keys := index.chats[chat.String()]
+	count := len(keys)
+	for key := range keys {
+		index.remove(key)
+	}
+	return count
+}
+
+// chatEntries returns all entries of the conversation
+func (index *messageIndex) chatEntries(chat ID) []*indexEntry {
+	keys := index.chats[chat.String()]
+	entries := make([]*indexEntry, 0, len(keys))
+	for key := range keys {
+		entries = append(entries, index.entries[key])
+	}
+	return entries
+}
+
+// sortedTerms returns all terms in order
+func (index *messageIndex) sortedTerms() []string {
+	if index.terms == nil {
+		terms := make([]string, 0, len(index.postings))
+		for term := range index.postings {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+		index.terms = terms
+	}
+	return index.terms
+}
+
+// lookup returns keys of messages containing the term,
+// or any word starts with it, e.g.: "hel" for "hello"
+func (index *messageIndex) lookup(term string) map[string]any {
+	r, size := utf8.DecodeRuneInString(term)
+	if size != len(term) || !isCJK(r) {
+		return index.lookupPrefix(term)
+	}
+	// single CJK character, collect bigrams containing it
+	keys := make(map[string]any, 16)
+	for item, postings := range index.postings {
+		if !strings.ContainsRune(item, r) {
+			continue
+		}
+		for key := range postings {
+			keys[key] = nil
+		}
+	}
+	return keys
+}
+
+func (index *messageIndex) lookupPrefix(prefix string) map[string]any {
+	terms := index.sortedTerms()
+	start := sort.SearchStrings(terms, prefix)
+	end := start
+	for end < len(terms) && strings.HasPrefix(terms[end], prefix) {
+		end++
+	}
+	if end-start == 1 {
+		return index.postings[terms[start]]
+	}
+	keys := make(map[string]any, 16)
+	for _, item := range terms[start:end] {
+		for key := range index.postings[item] {
+			keys[key] = nil
+		}
+	}
+	return keys
+}
+
+// search returns entries containing all terms, the latest first
+func (index *messageIndex) search(terms []string) []*indexEntry {
+	if len(terms) == 0 {
+		return nil
+	}
+	var matched map[string]any
+	for _, term := range terms {
+		keys := index.lookup(term)
+		if len(keys) == 0 {
+			return nil
+		} else if matched == nil {
+			matched = keys
+			continue
+		}
+		intersection := make(map[string]any, len(matched))
+		for key := range matched {
+			if _, exists := keys[key]; exists {
+				intersection[key] = nil
+			}
+		}
+		matched = intersection
+	}
+	entries := make([]*indexEntry, 0, len(matched))
+	for key := range matched {
+		entries = append(entries, index.entries[key])
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return TimeIsAfter(entries[j].time, entries[i].time)
+	})
+	return entries
+}
+
+/**
+ *  Message Index for User
+ *  ~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  file path: '.dim/protected/{ADDRESS}/search_index/{CHAT_ADDRESS}.js'
+ *
+ *  the index is split into segments by conversation,
+ *  so only the changed one will be written;
+ *  a segment is kept (even empty) for each indexed conversation
+ */
+
+func messageIndexDir(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "search_index")
+}
+
+func indexSegmentPath(db *Storage, user, chat ID) string {
+	filename := chat.Address().String() + ".js"
+	return PathJoin(messageIndexDir(db, user), filename)
+}
+
+// index file of old version: '.dim/protected/{ADDRESS}/search_index.js'
+func legacyIndexPath(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "search_index.js")
+}
+
+func loadMessageIndex(db *Storage, user ID) *messageIndex {
+	paths := db.Backend().List(messageIndexDir(db, user))
+	if len(paths) == 0 {
+		return nil
+	}
+	db.log("Loading message index for user: " + user.String())
+	index := newMessageIndex()
+	for _, path := range paths {
+		for _, item := range db.readList(path) {
+			dict, ok := item.(StringKeyMap)
+			if !ok {
+				continue
+			}
+			chat := ParseID(dict["chat"])
+			sender := ParseID(dict["sender"])
+			if chat == nil {
+				continue
+			} else if sender == nil {
+				// segment of old version, rebuild it
+				return nil
+			}
+			tokens := make([]string, 0, 8)
+			if terms, ok := dict["tokens"].([]interface{}); ok {
+				for _, term := range terms {
+					if text, ok := term.(string); ok {
+						tokens = append(tokens, text)
+					}
+				}
+			}
+			index.add(&indexEntry{
+				chat:   chat,
+				sender: sender,
+				sn:     ConvertUInt64(dict["sn"], 0),
+				time:   ConvertTime(dict["time"], nil),
+				tokens: tokens,
+			})
+		}
+	}
+	return index
+}
+
+func saveIndexSegment(db *Storage, user, chat ID, index *messageIndex) bool {
+	path := indexSegmentPath(db, user, chat)
+	entries := index.chatEntries(chat)
+	array := make([]StringKeyMap, 0, len(entries))
+	for _, item := range entries {
+		array = append(array, StringKeyMap{
+			"chat":   item.chat.String(),
+			"sender": item.sender.String(),
+			"sn":     item.sn,
+			"time":   TimeToFloat64(item.time),
+			"tokens": item.tokens,
+		})
+	}
+	return db.writeList(path, array)
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimpart/demo-go/sdk/extensions"
+)
+
+/**
+ *  Passphrase for private key encryption
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  Private keys are saved in keystore format (scrypt + AES-GCM);
+ *  files written by the old versions (plaintext, or AES with unsalted digest)
+ *  can still be read, and will be upgraded when saved again.
+ */
+
+func (db *Storage) Keystore() *Keystore {
+	return db.keystore
+}
+
+func (db *Storage) SetPassword(password string) {
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
+	db.keystore = NewKeystore(password)
+	db.legacyPassword = GeneratePassword(password)
+}
+
+// ChangePassword re-encrypts private keys of all local users with the new passphrase
+//
+// All secret files are encrypted into temporary files first, and then replace
+// the old ones; if any step failed, the old files will be restored.
+//
+// A plaintext file is only accepted when the old passphrase is empty.
+//
+// Returns: false if the old passphrase not matched
+func (db *Storage) ChangePassword(oldPassword, newPassword string) bool {
+	users := db.GetLocalUsers()
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
+	oldKeystore := NewKeystore(oldPassword)
+	oldLegacy := GeneratePassword(oldPassword)
+	// 1. decrypt all secret files with the old passphrase
+	secrets := make(map[string][]byte, len(users)*2)
+	for _, user := range users {
+		for _, path := range []string{identityKeyPath(db, user), communicationKeysPath(db, user)} {
+			data := db.backend.Read(path)
+			if data == nil {
+				continue
+			}
+			if oldPassword != "" && isPlainSecret(data, oldLegacy) {
+				// not encrypted with the old password
+				db.error("secret file not encrypted: " + path)
+				return false
+			}
+			info := db.decryptSecret(data, oldKeystore, oldLegacy)
+			if info == nil {
+				db.error("failed to decrypt with old password: " + path)
+				return false
+			}
+			secrets[path] = UTF8Encode(JSONEncode(info))
+		}
+	}
+	// 2. encrypt them with the new passphrase into temporary files
+	newKeystore := NewKeystore(newPassword)
+	paths := make([]string, 0, len(secrets))
+	for path, plaintext := range secrets {
+		data := db.encryptSecret(plaintext, newKeystore)
+		if data == nil || !db.backend.Write(secretTempPath(path), data) {
+			db.error("failed to save secret file: " + path)
+			db.backend.Remove(secretTempPath(path))
+			db.discardSecretFiles(paths)
+			return false
+		}
+		paths = append(paths, path)
+	}
+	// 3. replace the secret files, the old ones are moved to backups
+	for index, path := range paths {
+		replaced := index
+		if db.backend.Rename(path, BackupPath(path)) {
+			replaced = index + 1
+			if db.backend.Rename(secretTempPath(path), path) {
+				continue
+			}
+		}
+		db.error("failed to replace secret file: " + path)
+		db.restoreSecretFiles(paths[:replaced])
+		db.discardSecretFiles(paths[index:])
+		return false
+	}
+	// 4. the backups were encrypted with the old passphrase
+	for _, path := range paths {
+		db.backend.Remove(BackupPath(path))
+	}
+	db.keystore = newKeystore
+	db.legacyPassword = GeneratePassword(newPassword)
+	db.log("Password changed for local users")
+	return true
+}
+
+// private
+func (db *Storage) restoreSecretFiles(paths []string) {
+	for _, path := range paths {
+		if !db.backend.Rename(BackupPath(path), path) {
+			db.error("failed to restore secret file: " + path)
+		}
+	}
+}
+
+// private
+func (db *Storage) discardSecretFiles(paths []string) {
+	for _, path := range paths {
+		db.backend.Remove(secretTempPath(path))
+	}
+}
+
+// temporary file for the re-encrypted secret: '{path}.tmp'
+func secretTempPath(path string) string {
+	return path + ".tmp"
+}
+
+// private
+func (db *Storage) decryptSecret(data []byte, keystore *Keystore, legacy SymmetricKey) interface{} {
+	if data == nil {
+		return nil
+	} else if IsKeystoreData(data) {
+		if keystore == nil {
+			db.error("password required")
+			return nil
+		}
+		return decodeJSON(keystore.Open(data))
+	}
+	// legacy formats
+	if legacy != nil {
+		if info := decodeJSON(legacyDecrypt(legacy, data)); info != nil {
+			return info
+		}
+	}
+	return decodeJSON(data)
+}
+
+// private
+func (db *Storage) encryptSecret(plaintext []byte, keystore *Keystore) []byte {
+	if keystore == nil {
+		db.warning("password not set, saving private key in plaintext")
+		return plaintext
+	}
+	return keystore.Seal(plaintext)
+}
+
+// isPlainSecret checks whether the secret file is neither in keystore format
+// nor encrypted by the legacy password
+func isPlainSecret(data []byte, legacy SymmetricKey) bool {
+	if IsKeystoreData(data) {
+		return false
+	}
+	return decodeJSON(legacyDecrypt(legacy, data)) == nil
+}
+
+func legacyDecrypt(password SymmetricKey, data []byte) (plaintext []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			// not encrypted with this password
+			plaintext = nil
+		}
+	}()
+	return password.Decrypt(data, password.Map())
+}
@@ -0,0 +1,335 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"sync"
+
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+const (
+	kvOpPut    = 1
+	kvOpDelete = 2
+
+	// compact the file when garbage exceeds this size (and half of the file)
+	kvCompactThreshold = 1 << 20
+)
+
+var errKVRecord = errors.New("kv record error")
+
+/**
+ *  Key-Value Backend
+ *  ~~~~~~~~~~~~~~~~~
+ *
+ *  Embedded key-value store in a single append-only file,
+ *  all records are loaded into memory when opened.
+ *
+ *  Record format:
+ *      [op:1][key length:uvarint][value length:uvarint][key][value][crc32:4]
+ */
+type KVBackend struct {
+	//Backend
+
+	path string
+	file *os.File
+
+	records map[string][]byte
+	size    int64 // file size
+	garbage int64 // bytes of overwritten & deleted records
+
+	lock sync.Mutex
+}
+
+// NewKVBackend opens the data file, creates it if not exists
+//
+// Returns: nil on error
+func NewKVBackend(path string) *KVBackend {
+	backend := &KVBackend{
+		path:    path,
+		records: make(map[string][]byte, 1024),
+	}
+	if err := backend.open(); err != nil {
+		LogError("KVBackend > failed to open " + path + ": " + err.Error())
+		return nil
+	}
+	return backend
+}
+
+// Override
+func (backend *KVBackend) Read(path string) []byte {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	data, exists := backend.records[path]
+	if !exists {
+		return nil
+	}
+	return cloneBytes(data)
+}
+
+// Override
+func (backend *KVBackend) Write(path string, data []byte) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	if !backend.append(kvOpPut, path, data) {
+		return false
+	}
+	if old, exists := backend.records[path]; exists {
+		backend.garbage += kvRecordSize(path, old)
+	}
+	backend.records[path] = cloneBytes(data)
+	backend.checkCompact()
+	return true
+}
+
+// Override
+func (backend *KVBackend) Exists(path string) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	_, exists := backend.records[path]
+	return exists
+}
+
+// Override
+func (backend *KVBackend) Remove(path string) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	old, exists := backend.records[path]
+	if !exists {
+		return false
+	} else if !backend.append(kvOpDelete, path, nil) {
+		return false
+	}
+	delete(backend.records, path)
+	backend.garbage += kvRecordSize(path, old) + kvRecordSize(path, nil)
+	backend.checkCompact()
+	return true
+}
+
+// Override
+func (backend *KVBackend) Rename(from, to string) bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	data, exists := backend.records[from]
+	if !exists {
+		return false
+	}
+	// put the new one first, so the data won't be lost
+	if !backend.append(kvOpPut, to, data) {
+		return false
+	} else if old, exists := backend.records[to]; exists {
+		backend.garbage += kvRecordSize(to, old)
+	}
+	backend.records[to] = data
+	if !backend.append(kvOpDelete, from, nil) {
+		return false
+	}
+	delete(backend.records, from)
+	backend.garbage += kvRecordSize(from, data) + kvRecordSize(from, nil)
+	backend.checkCompact()
+	return true
+}
+
+// Override
+func (backend *KVBackend) List(dir string) []string {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	return listPaths(backend.records, dir)
+}
+
+// Close closes the data file
+func (backend *KVBackend) Close() bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	if backend.file == nil {
+		return false
+	}
+	err := backend.file.Close()
+	backend.file = nil
+	return err == nil
+}
+
+// Compact rewrites the data file with live records only
+func (backend *KVBackend) Compact() bool {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+	return backend.compact()
+}
+
+// private
+func (backend *KVBackend) open() error {
+	// NOTICE: not 'MakeDirs', which panics on error
+	if err := os.MkdirAll(PathDir(backend.path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(backend.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(backend.path)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	offset := backend.load(data)
+	if offset < int64(len(data)) {
+		// drop the broken tail, which may be caused by a crash while writing
+		LogWarning("KVBackend > data file truncated: " + backend.path)
+		if err = file.Truncate(offset); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	if _, err = file.Seek(offset, 0); err != nil {
+		_ = file.Close()
+		return err
+	}
+	backend.file = file
+	backend.size = offset
+	return nil
+}
+
+// private
+func (backend *KVBackend) load(data []byte) int64 {
+	var offset int64
+	for offset < int64(len(data)) {
+		op, key, value, size, err := kvDecode(data[offset:])
+		if err != nil {
+			break
+		}
+		if old, exists := backend.records[key]; exists {
+			backend.garbage += kvRecordSize(key, old)
+		}
+		if op == kvOpPut {
+			backend.records[key] = value
+		} else {
+			delete(backend.records, key)
+			backend.garbage += size
+		}
+		offset += size
+	}
+	return offset
+}
+
+// private
+func (backend *KVBackend) append(op byte, key string, value []byte) bool {
+	if backend.file == nil {
+		return false
+	}
+	record := kvEncode(op, key, value)
+	if _, err := backend.file.Write(record); err != nil {
+		LogError("KVBackend > failed to write: " + err.Error())
+		return false
+	} else if err = backend.file.Sync(); err != nil {
+		LogError("KVBackend > failed to sync: " + err.Error())
+		return false
+	}
+	backend.size += int64(len(record))
+	return true
+}
+
+// private
+func (backend *KVBackend) checkCompact() {
+	if backend.garbage > kvCompactThreshold && backend.garbage*2 > backend.size {
+		backend.compact()
+	}
+}
+
+// private
+func (backend *KVBackend) compact() bool {
+	if backend.file == nil {
+		return false
+	}
+	tmp := backend.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		LogError("KVBackend > failed to compact: " + err.Error())
+		return false
+	}
+	var size int64
+	for key, value := range backend.records {
+		record := kvEncode(kvOpPut, key, value)
+		if _, err = file.Write(record); err != nil {
+			break
+		}
+		size += int64(len(record))
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, backend.path)
+	}
+	if err != nil {
+		LogError("KVBackend > failed to compact: " + err.Error())
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return false
+	}
+	_ = backend.file.Close()
+	backend.file = file
+	backend.size = size
+	backend.garbage = 0
+	return true
+}
+
+func kvRecordSize(key string, value []byte) int64 {
+	return int64(len(kvEncode(kvOpPut, key, value)))
+}
+
+func kvEncode(op byte, key string, value []byte) []byte {
+	var header [1 + 2*binary.MaxVarintLen64]byte
+	header[0] = op
+	pos := 1
+	pos += binary.PutUvarint(header[pos:], uint64(len(key)))
+	pos += binary.PutUvarint(header[pos:], uint64(len(value)))
+	buffer := make([]byte, 0, pos+len(key)+len(value)+4)
+	buffer = append(buffer, header[:pos]...)
+	buffer = append(buffer, key...)
+	buffer = append(buffer, value...)
+	var checksum [4]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(buffer))
+	return append(buffer, checksum[:]...)
+}
+
+func kvDecode(data []byte) (op byte, key string, value []byte, size int64, err error) {
+	if len(data) < 1 {
+		return 0, "", nil, 0, errKVRecord
+	}
+	op = data[0]
+	if op != kvOpPut && op != kvOpDelete {
+		return 0, "", nil, 0, errKVRecord
+	}
+	pos := 1
+	keyLen, n := binary.Uvarint(data[pos:])
+	if n <= 0 {
+		return 0, "", nil, 0, errKVRecord
+	}
+	pos += n
+	valueLen, n := binary.Uvarint(data[pos:])
+	if n <= 0 {
+		return 0, "", nil, 0, errKVRecord
+	}
+	pos += n
+	// check each length against the rest, so the sum won't overflow
+	if keyLen > uint64(len(data)-pos) {
+		return 0, "", nil, 0, errKVRecord
+	}
+	keyEnd := pos + int(keyLen)
+	if valueLen > uint64(len(data)-keyEnd) {
+		return 0, "", nil, 0, errKVRecord
+	}
+	end := keyEnd + int(valueLen)
+	if len(data)-end < 4 {
+		return 0, "", nil, 0, errKVRecord
+	}
+	checksum := binary.BigEndian.Uint32(data[end : end+4])
+	if checksum != crc32.ChecksumIEEE(data[:end]) {
+		return 0, "", nil, 0, errKVRecord
+	}
+	key = string(data[pos:keyEnd])
+	value = cloneBytes(data[keyEnd:end])
+	return op, key, value, int64(end + 4), nil
+}
@@ -13,12 +13,16 @@ import (
 
 // Override
 func (db *Storage) GetLoginCommandMessage(user ID) Pair[LoginCommand, ReliableMessage] {
+	db.loginLock.Lock()
+	defer db.loginLock.Unlock()
 	cmd, msg := getLoginInfo(db, user)
 	return NewPair[LoginCommand, ReliableMessage](cmd, msg)
 }
 
 // Override
 func (db *Storage) SaveLoginCommandMessage(user ID, cmd LoginCommand, msg ReliableMessage) bool {
+	db.loginLock.Lock()
+	defer db.loginLock.Unlock()
 	if !cacheLoginInfo(db, user, cmd, msg) {
 		return false
 	}
@@ -15,6 +15,8 @@ func (db *Storage) SaveMeta(meta Meta, entity ID) bool {
 	if !MetaMatchID(entity, meta) {
 		return false
 	}
+	db.metaLock.Lock()
+	defer db.metaLock.Unlock()
 	// 2. cache it
 	db.metaTable[entity.String()] = meta
 	// 3. save into local storage
@@ -24,7 +26,14 @@ func (db *Storage) SaveMeta(meta Meta, entity ID) bool {
 // Override
 func (db *Storage) GetMeta(entity ID) Meta {
 	// 1. try from memory cache
+	db.metaLock.RLock()
 	meta := db.metaTable[entity.String()]
+	db.metaLock.RUnlock()
+	if meta == nil {
+		db.metaLock.Lock()
+		defer db.metaLock.Unlock()
+		meta = db.metaTable[entity.String()]
+	}
 	if meta == nil {
 		// 2. try from local storage
 		meta = loadMeta(db, entity)
@@ -39,6 +48,32 @@ func (db *Storage) GetMeta(entity ID) Meta {
 	return meta
 }
 
+// Override
+// NOTICE: scans all meta files, for CLI tools only
+func (db *Storage) GetEntities() []ID {
+	dir := PathJoin(db.Root(), "public")
+	paths := db.backend.List(dir)
+	entities := make([]ID, 0, len(paths))
+	for _, path := range paths {
+		if PathBase(path) != "meta.js" {
+			continue
+		}
+		// '{root}/public/{ADDRESS}/meta.js'
+		address := ParseAddress(PathBase(PathDir(path)))
+		meta := ParseMeta(db.readMap(path))
+		if address == nil || meta == nil {
+			continue
+		}
+		did := CreateID(meta.Seed(), address, "")
+		if MetaMatchID(did, meta) {
+			entities = append(entities, did)
+		} else {
+			db.warning("meta not match: " + path)
+		}
+	}
+	return entities
+}
+
 /**
  *  Meta file for Entities (User/Group)
  *  ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// SchemaVersion is the latest version of the storage layout
+const SchemaVersion = 1
+
+// Migration upgrades the storage layout to the version
+//
+// Steps must be idempotent, they may run again if the process was
+// interrupted before the schema version saved
+type Migration struct {
+	Version int
+	Name    string
+	Migrate func(db *Storage) bool
+}
+
+var migrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "baseline layout",
+		Migrate: func(db *Storage) bool { return true },
+	},
+}
+
+// RegisterMigration adds a migration step, steps run in version order
+func RegisterMigration(step *Migration) {
+	for _, item := range migrations {
+		if item.Version == step.Version {
+			panic(fmt.Sprintf("duplicated migration version: %d", step.Version))
+		}
+	}
+	migrations = append(migrations, step)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+/**
+ *  Schema Version
+ *  ~~~~~~~~~~~~~~
+ *
+ *  file path: '.dim/schema.js'
+ */
+
+func schemaPath(db *Storage) string {
+	return PathJoin(db.Root(), "schema.js")
+}
+
+// GetSchemaVersion returns the version of the storage layout, 0 for legacy installs
+func (db *Storage) GetSchemaVersion() int {
+	info := db.readMap(schemaPath(db))
+	if info == nil {
+		return 0
+	}
+	return ConvertInt(info["version"], 0)
+}
+
+// private
+func (db *Storage) setSchemaVersion(version int) bool {
+	info := NewMap()
+	info["version"] = version
+	info["time"] = TimeToFloat64(TimeNow())
+	return db.writeMap(schemaPath(db), info)
+}
+
+// Migrate runs the pending migration steps in order
+//
+// Call it once after the root directory is final, before using the storage.
+//
+// Returns: false if any step failed, the later steps will not run
+func (db *Storage) Migrate() bool {
+	current := db.GetSchemaVersion()
+	for _, step := range migrations {
+		if step.Version <= current {
+			continue
+		}
+		db.log(fmt.Sprintf("Migrating to schema v%d: %s", step.Version, step.Name))
+		if !step.Migrate(db) {
+			db.error(fmt.Sprintf("Failed to migrate to schema v%d: %s", step.Version, step.Name))
+			return false
+		}
+		if !db.setSchemaVersion(step.Version) {
+			return false
+		}
+		current = step.Version
+	}
+	return true
+}
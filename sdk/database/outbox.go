@@ -0,0 +1,143 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// max records kept in the outbox of each user
+const OutboxMaxSize = 1024
+
+//-------- OutboxTable
+
+// Override
+func (db *Storage) SaveOutgoingMessage(msg *OutgoingMessage) bool {
+	db.outboxLock.Lock()
+	defer db.outboxLock.Unlock()
+	messages := db.getOutgoingMessages(msg.Sender)
+	if old := db.findOutgoingMessage(msg.Sender, msg.Receiver, msg.SN); old != nil {
+		// sent again, replace the record
+		*old = *msg
+		return saveOutgoingMessage(db, old)
+	}
+	messages = append(messages, msg)
+	for len(messages) > OutboxMaxSize {
+		// drop the oldest one
+		removeOutgoingMessage(db, messages[0])
+		messages = messages[1:]
+	}
+	db.outboxTable[msg.Sender.String()] = messages
+	return saveOutgoingMessage(db, msg)
+}
+
+// Override
+func (db *Storage) GetOutgoingMessage(sender, receiver ID, sn SerialNumberType) *OutgoingMessage {
+	db.outboxLock.Lock()
+	defer db.outboxLock.Unlock()
+	msg := db.findOutgoingMessage(sender, receiver, sn)
+	if msg == nil {
+		return nil
+	}
+	clone := *msg
+	return &clone
+}
+
+// Override
+func (db *Storage) UpdateOutgoingMessageState(sender, receiver ID, sn SerialNumberType, state int) bool {
+	db.outboxLock.Lock()
+	defer db.outboxLock.Unlock()
+	msg := db.findOutgoingMessage(sender, receiver, sn)
+	if msg == nil || msg.State >= state {
+		// not found, or state not changed
+		return false
+	}
+	msg.State = state
+	return saveOutgoingMessage(db, msg)
+}
+
+// private
+func (db *Storage) findOutgoingMessage(sender, receiver ID, sn SerialNumberType) *OutgoingMessage {
+	messages := db.getOutgoingMessages(sender)
+	// search from the newest one
+	for index := len(messages) - 1; index >= 0; index-- {
+		item := messages[index]
+		if item.SN == sn && item.Receiver.Equal(receiver) {
+			return item
+		}
+	}
+	return nil
+}
+
+// private
+func (db *Storage) getOutgoingMessages(sender ID) []*OutgoingMessage {
+	messages := db.outboxTable[sender.String()]
+	if messages == nil {
+		messages = loadOutbox(db, sender)
+		db.outboxTable[sender.String()] = messages
+	}
+	return messages
+}
+
+/**
+ *  Outgoing Messages for User
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *
+ *  file path: '.dim/protected/{ADDRESS}/outbox/{RECEIVER_ADDRESS}_{SN}.js'
+ *
+ *  one record for each message, so a new state won't rewrite the others
+ */
+
+func outboxDir(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "outbox")
+}
+
+func outgoingMessagePath(db *Storage, msg *OutgoingMessage) string {
+	filename := msg.Receiver.Address().String() + "_" + strconv.FormatUint(uint64(msg.SN), 10) + ".js"
+	return PathJoin(outboxDir(db, msg.Sender), filename)
+}
+
+// outbox file of old version: '.dim/protected/{ADDRESS}/outbox.js'
+func legacyOutboxPath(db *Storage, user ID) string {
+	return PathJoin(db.Root(), "protected", user.Address().String(), "outbox.js")
+}
+
+func loadOutbox(db *Storage, user ID) []*OutgoingMessage {
+	db.log("Loading outbox for user: " + user.String())
+	paths := db.backend.List(outboxDir(db, user))
+	records := make([]StringKeyMap, 0, len(paths))
+	for _, path := range paths {
+		if info := db.readMap(path); info != nil {
+			records = append(records, info)
+		}
+	}
+	messages := ConvertOutgoingMessages(records)
+	if path := legacyOutboxPath(db, user); db.isExist(path) {
+		// split the old file into records
+		legacy := ConvertOutgoingMessages(readMapList(db, path))
+		for _, item := range legacy {
+			saveOutgoingMessage(db, item)
+		}
+		db.remove(path)
+		messages = append(messages, legacy...)
+	}
+	// in sending order
+	sort.SliceStable(messages, func(i, j int) bool {
+		return TimeIsBefore(messages[j].Time, messages[i].Time)
+	})
+	return messages
+}
+
+func saveOutgoingMessage(db *Storage, msg *OutgoingMessage) bool {
+	array := RevertOutgoingMessages([]*OutgoingMessage{msg})
+	return db.writeMap(outgoingMessagePath(db, msg), array[0])
+}
+
+func removeOutgoingMessage(db *Storage, msg *OutgoingMessage) bool {
+	return db.remove(outgoingMessagePath(db, msg))
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"fmt"
+
 	. "github.com/dimchat/mkm-go/crypto"
 	. "github.com/dimchat/mkm-go/format"
 	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
 	. "github.com/dimchat/plugins-go/crypto"
 	. "github.com/dimpart/demo-go/sdk/common/db"
 	. "github.com/dimpart/demo-go/sdk/utils"
@@ -13,6 +16,8 @@ import (
 
 // Override
 func (db *Storage) SavePrivateKey(key PrivateKey, keyType string, user ID) bool {
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
 	if keyType == META_KEY {
 		if !cacheIdentityKey(db, user, key) {
 			return false
@@ -28,11 +33,15 @@ func (db *Storage) SavePrivateKey(key PrivateKey, keyType string, user ID) bool
 
 // Override
 func (db *Storage) GetPrivateKeysForDecryption(user ID) []DecryptKey {
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
 	return getDecryptionKeys(db, user)
 }
 
 // Override
 func (db *Storage) GetPrivateKeyForSignature(user ID) SignKey {
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
 	keys := getCommunicationKeys(db, user)
 	if len(keys) > 0 {
 		// sign message with communication key
@@ -44,9 +53,23 @@ func (db *Storage) GetPrivateKeyForSignature(user ID) SignKey {
 
 // Override
 func (db *Storage) GetPrivateKeyForVisaSignature(user ID) SignKey {
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
 	return getIdentityKey(db, user)
 }
 
+// Override
+func (db *Storage) PruneCommunicationKeys(user ID, grace Duration) int {
+	db.keyLock.Lock()
+	defer db.keyLock.Unlock()
+	count := pruneCommunicationKeys(db, user, grace)
+	if count > 0 {
+		keys := getCommunicationKeys(db, user)
+		saveCommunicationKeys(db, user, keys)
+	}
+	return count
+}
+
 /**
  *  Private Key file for Local Users
  *  ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -56,8 +79,14 @@ func (db *Storage) GetPrivateKeyForVisaSignature(user ID) SignKey {
  *
  *  2. Communication Key - paired to visa.key, VOLATILE
  *     file path: '.dim/private/{ADDRESS}/secret_keys'
+ *
+ *     the current key is in the front, the older ones are marked with
+ *     "retired" time, and will be pruned after the grace period
  */
 
+// time when the communication key was replaced by a new one
+const retiredKey = "retired"
+
 func identityKeyPath(db *Storage, user ID) string {
 	return PathJoin(db.Root(), "private", user.Address().String(), "secret.js")
 }
@@ -69,22 +98,19 @@ func communicationKeysPath(db *Storage, user ID) string {
 func loadIdentityKey(db *Storage, user ID) PrivateKey {
 	path := identityKeyPath(db, user)
 	db.log("Loading identity key: " + path)
-	data := db.readSecret(path)
-	if data == nil {
+	info := db.readSecret(path)
+	if info == nil {
 		return nil
 	}
-	json := UTF8Decode(data)
-	dict := JSONDecodeMap(json)
-	return ParsePrivateKey(dict)
+	return ParsePrivateKey(info)
 }
 func loadCommunicationKeys(db *Storage, user ID) []PrivateKey {
 	keys := make([]PrivateKey, 0, 1)
 	path := communicationKeysPath(db, user)
 	db.log("Loading communication keys: " + path)
-	data := db.readSecret(path)
-	if data != nil {
-		json := UTF8Decode(data)
-		arr, _ := JSONDecode(json).([]interface{})
+	info := db.readSecret(path)
+	if info != nil {
+		arr, _ := info.([]interface{})
 		for _, item := range arr {
 			k := ParsePrivateKey(item)
 			if k == nil {
@@ -147,6 +173,17 @@ func getCommunicationKeys(db *Storage, user ID) []PrivateKey {
 		keys = loadCommunicationKeys(db, user)
 		// 3. cache them
 		db.communicationKeyTable[user.String()] = keys
+		// 4. older keys saved before the retired time recorded,
+		//    start their grace period from now
+		changed := len(keys) > 1 && stampRetiredKeys(keys[1:])
+		// 5. drop the expired ones
+		if pruneCommunicationKeys(db, user, DurationOfDays(VisaKeyGracePeriodDays)) > 0 {
+			keys = db.communicationKeyTable[user.String()]
+			changed = true
+		}
+		if changed {
+			saveCommunicationKeys(db, user, keys)
+		}
 	}
 	return keys
 }
@@ -194,8 +231,16 @@ func cacheCommunicationKey(db *Storage, user ID, key PrivateKey) bool {
 		return false // nothing changed
 	} else if index > 0 {
 		keys = removeKey(keys, index) // move to the front
-	} else if len(keys) > 2 {
-		keys = keys[:2] // keep only last three records
+	} else if pruneCommunicationKeys(db, user, DurationOfDays(VisaKeyGracePeriodDays)) > 0 {
+		// drop the keys retired too long ago only,
+		// the others are still needed for decrypting
+		keys = db.communicationKeyTable[user.String()]
+	}
+	// the current key is in use again
+	key.Remove(retiredKey)
+	// retire the previous one
+	if len(keys) > 0 && keys[0].Get(retiredKey) == nil {
+		keys[0].Set(retiredKey, TimeToFloat64(TimeNow()))
 	}
 	keys = insertKey(keys, key)
 	db.communicationKeyTable[user.String()] = keys
@@ -204,6 +249,46 @@ func cacheCommunicationKey(db *Storage, user ID, key PrivateKey) bool {
 	return true
 }
 
+func pruneCommunicationKeys(db *Storage, user ID, grace Duration) int {
+	keys := getCommunicationKeys(db, user)
+	if len(keys) < 2 {
+		return 0
+	}
+	deadline := grace.SubtractFrom(TimeNow())
+	// the current key is always kept
+	arr := make([]PrivateKey, 0, len(keys))
+	arr = append(arr, keys[0])
+	for _, item := range keys[1:] {
+		retired := ConvertTime(item.Get(retiredKey), nil)
+		if !TimeIsNil(retired) && TimeIsBefore(deadline, retired) {
+			continue
+		}
+		arr = append(arr, item)
+	}
+	count := len(keys) - len(arr)
+	if count > 0 {
+		db.communicationKeyTable[user.String()] = arr
+		// reset decryption keys
+		delete(db.decryptionKeyTable, user.String())
+		db.log(fmt.Sprintf("Pruned %d retired key(s): %s", count, user.String()))
+	}
+	return count
+}
+
+// stampRetiredKeys marks the keys without retired time as retired now
+//
+// Returns: true if any key marked
+func stampRetiredKeys(keys []PrivateKey) bool {
+	changed := false
+	for _, item := range keys {
+		if item.Get(retiredKey) == nil {
+			item.Set(retiredKey, TimeToFloat64(TimeNow()))
+			changed = true
+		}
+	}
+	return changed
+}
+
 func findKey(keys []PrivateKey, key PrivateKey) int {
 	for index, item := range keys {
 		if key.Equal(item) {
@@ -213,7 +298,9 @@ func findKey(keys []PrivateKey, key PrivateKey) int {
 	return -1
 }
 func removeKey(keys []PrivateKey, index int) []PrivateKey {
-	return append(keys[:index], keys[index+1:]...)
+	arr := make([]PrivateKey, 0, len(keys)-1)
+	arr = append(arr, keys[:index]...)
+	return append(arr, keys[index+1:]...)
 }
 func insertKey(keys []PrivateKey, key PrivateKey) []PrivateKey {
 	arr := make([]PrivateKey, 0, len(keys)+1)
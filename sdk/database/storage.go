@@ -2,12 +2,13 @@ package db
 
 import (
 	"fmt"
+	"sync"
 
 	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
-	. "github.com/dimchat/plugins-go/crypto"
 	. "github.com/dimpart/demo-go/sdk/common/db"
 	. "github.com/dimpart/demo-go/sdk/common/protocol"
 	. "github.com/dimpart/demo-go/sdk/extensions"
@@ -24,10 +25,18 @@ type Database interface {
 
 	UserDBI
 	ContactDBI
+	ContactInfoDBI
+	MuteDBI
+	BlockDBI
+	OutboxDBI
+	MessageHistoryDBI
+	MessageIndexDBI
 	GroupDBI
 
 	GroupHistoryDBI
 
+	CheckerDBI
+
 	// root directory for database
 	SetRoot(root string)
 }
@@ -41,7 +50,12 @@ type Storage struct {
 
 	root string
 
-	password SymmetricKey
+	backend Backend
+
+	// passphrase for private keys, nil means plaintext
+	keystore *Keystore
+	// key derived by the old way, for reading legacy files
+	legacyPassword SymmetricKey
 
 	//
 	//  memory caches
@@ -59,18 +73,52 @@ type Storage struct {
 	loginCommandTable map[string]LoginCommand    // ID -> Login Command
 	loginMessageTable map[string]ReliableMessage // ID -> Login Message
 
-	users        []ID
-	contactTable map[string][]ID // user contacts: ID -> []ID
+	users            []ID
+	contactTable     map[string][]ID               // user contacts: ID -> []ID
+	contactInfoTable map[string][]*ContactInfo     // contact settings: ID -> []info
+	muteTable        map[string][]ID               // mute-list: ID -> []ID
+	blockTable       map[string][]ID               // block-list: ID -> []ID
+	outboxTable      map[string][]*OutgoingMessage // sent messages: ID -> []msg
+
+	conversationTable map[string][]ID              // chat list: ID -> []ID
+	historyTable      map[string][]*HistoryMessage // chat history: 'ID|ID' -> []msg
+	indexTable        map[string]*messageIndex     // search index: ID -> index
 
 	memberTable map[string][]ID // group members: ID -> []ID
+
+	documentTimeTable map[string]Time // checker: ID -> SDT
+	historyTimeTable  map[string]Time // checker: GID -> GHT
+
+	//
+	//  table locks
+	//
+
+	keyLock         sync.Mutex   // private keys
+	metaLock        sync.RWMutex // meta
+	documentLock    sync.RWMutex // documents
+	ansLock         sync.RWMutex // ANS records
+	loginLock       sync.Mutex   // login info
+	userLock        sync.Mutex   // local users
+	contactLock     sync.Mutex   // contacts
+	contactInfoLock sync.Mutex   // contact settings
+	muteLock        sync.Mutex   // mute-list
+	blockLock       sync.Mutex   // block-list
+	outboxLock      sync.Mutex   // sent messages
+	historyLock     sync.Mutex   // conversations, chat history & search index
+	memberLock      sync.RWMutex // group members
+	timesLock       sync.Mutex   // checker times
 }
 
 func NewStorage(root string) *Storage {
+	return NewStorageWithBackend(root, NewFileBackend())
+}
+
+func NewStorageWithBackend(root string, backend Backend) *Storage {
 	db := &Storage{
 
 		root: root,
 
-		password: NewPlainKey(),
+		backend: backend,
 
 		// private keys
 		identityKeyTable:      make(map[string]PrivateKey, 8),
@@ -91,11 +139,25 @@ func NewStorage(root string) *Storage {
 		loginMessageTable: make(map[string]ReliableMessage, 1024),
 
 		// local users
-		users:        make([]ID, 0, 1),
+		users:        nil, // lazy load
 		contactTable: make(map[string][]ID, 1),
 
+		contactInfoTable: make(map[string][]*ContactInfo, 1),
+		muteTable:        make(map[string][]ID, 1),
+		blockTable:       make(map[string][]ID, 1),
+		outboxTable:      make(map[string][]*OutgoingMessage, 1),
+
+		// chat history
+		conversationTable: make(map[string][]ID, 1),
+		historyTable:      make(map[string][]*HistoryMessage, 16),
+		indexTable:        make(map[string]*messageIndex, 1),
+
 		// group info
 		memberTable: make(map[string][]ID, 1024),
+
+		// checker times (lazy load)
+		documentTimeTable: nil,
+		historyTimeTable:  nil,
 	}
 	// load ANS
 	db.ansTable = loadANS(db)
@@ -103,17 +165,6 @@ func NewStorage(root string) *Storage {
 	return db
 }
 
-/**
- *  Password for private key encryption
- *  ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
- */
-func (db *Storage) Password() SymmetricKey {
-	return db.password
-}
-func (db *Storage) SetPassword(password string) {
-	db.password = GeneratePassword(password)
-}
-
 /**
  *  Root Directory
  *  ~~~~~~~~~~~~~~
@@ -124,11 +175,20 @@ func (db *Storage) Root() string {
 	return db.root
 }
 func (db *Storage) SetRoot(root string) {
-	if PathIsExist(root) {
-		db.root = root
-	} else {
+	if _, ok := db.backend.(*FileBackend); ok && !PathIsExist(root) {
 		panic(root)
 	}
+	db.root = root
+}
+
+/**
+ *  Storage Backend
+ *  ~~~~~~~~~~~~~~~
+ *
+ *  Files, memory or key-value store
+ */
+func (db *Storage) Backend() Backend {
+	return db.backend
 }
 
 // Directory for MKM entity: '.dim/mkm/{zzz}/{ADDRESS}'
@@ -143,27 +203,35 @@ func (db *Storage) mkmDir(identifier ID) string {
 	return PathJoin(db.Root(), "public", address)
 }
 
-func (db *Storage) prepareDir(filepath string) bool {
-	dir := PathDir(filepath)
-	return MakeDirs(dir)
-}
-
 //
 //  DOS
 //
 
-//func (db *Storage) isExist(path string) bool {
-//	return PathIsExist(path)
-//}
-//func (db *Storage) remove(path string) bool {
-//	return PathRemove(path)
-//}
+func (db *Storage) isExist(path string) bool {
+	return db.backend.Exists(path)
+}
+func (db *Storage) remove(path string) bool {
+	return db.backend.Remove(path)
+}
 
 func (db *Storage) readText(path string) string {
-	return ReadTextFile(path)
+	data := db.backend.Read(path)
+	if data == nil {
+		return ""
+	}
+	return UTF8Decode(data)
+}
+func (db *Storage) readJSON(path string) interface{} {
+	info := decodeJSON(db.backend.Read(path))
+	if info == nil && db.isExist(path) {
+		// file damaged, try the previous version
+		db.warning("failed to parse file, try backup: " + path)
+		info = decodeJSON(db.backend.Read(BackupPath(path)))
+	}
+	return info
 }
 func (db *Storage) readMap(path string) StringKeyMap {
-	info := ReadJSONFile(path)
+	info := db.readJSON(path)
 	if info == nil {
 		return nil
 	}
@@ -174,7 +242,7 @@ func (db *Storage) readMap(path string) StringKeyMap {
 	return dict
 }
 func (db *Storage) readList(path string) []interface{} {
-	info := ReadJSONFile(path)
+	info := db.readJSON(path)
 	if info == nil {
 		return nil
 	}
@@ -184,40 +252,53 @@ func (db *Storage) readList(path string) []interface{} {
 	}
 	return array
 }
-func (db *Storage) readSecret(path string) []byte {
-	data := ReadBinaryFile(path)
+func (db *Storage) readSecret(path string) interface{} {
+	info := db.decryptSecret(db.backend.Read(path), db.keystore, db.legacyPassword)
+	if info == nil && db.isExist(path) {
+		// file damaged, try the previous version
+		db.warning("failed to parse secret file, try backup: " + path)
+		info = db.decryptSecret(db.backend.Read(BackupPath(path)), db.keystore, db.legacyPassword)
+	}
+	return info
+}
+
+func readMapList(db *Storage, path string) []StringKeyMap {
+	array := db.readList(path)
+	records := make([]StringKeyMap, 0, len(array))
+	for _, item := range array {
+		if dict, ok := item.(StringKeyMap); ok {
+			records = append(records, dict)
+		}
+	}
+	return records
+}
+
+func decodeJSON(data []byte) interface{} {
 	if data == nil {
 		return nil
 	}
-	password := db.password
-	return password.Decrypt(data, password.Map())
+	return JSONDecode(UTF8Decode(data))
 }
 
 func (db *Storage) writeText(path string, text string) bool {
-	if !db.prepareDir(path) {
-		panic(path)
-	}
-	return WriteTextFile(path, text)
+	return db.backend.Write(path, UTF8Encode(text))
+}
+func (db *Storage) writeJSON(path string, object interface{}) bool {
+	json := JSONEncode(object)
+	return db.backend.Write(path, UTF8Encode(json))
 }
 func (db *Storage) writeMap(path string, container StringKeyMap) bool {
-	if !db.prepareDir(path) {
-		panic(path)
-	}
-	return WriteJSONFile(path, container)
+	return db.writeJSON(path, container)
 }
 func (db *Storage) writeList(path string, container []StringKeyMap) bool {
-	if !db.prepareDir(path) {
-		panic(path)
-	}
-	return WriteJSONFile(path, container)
+	return db.writeJSON(path, container)
 }
 func (db *Storage) writeSecret(path string, data []byte) bool {
-	if !db.prepareDir(path) {
-		panic(path)
+	binary := db.encryptSecret(data, db.keystore)
+	if binary == nil {
+		return false
 	}
-	password := db.password
-	binary := password.Encrypt(data, password.Map())
-	return WriteBinaryFile(path, binary)
+	return db.backend.Write(path, binary)
 }
 
 //
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/ext"
+	. "github.com/dimpart/demo-go/sdk/extensions"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+var loadOnce sync.Once
+
+func loadLibraries() {
+	loadOnce.Do(func() {
+		CommonExtensionLoader{}.Load()
+		CommonPluginLoader{}.Load()
+		LogLevel = 0
+	})
+}
+
+// run with '-race' to check the locks of Storage
+func TestStorageConcurrentAccess(t *testing.T) {
+	loadLibraries()
+	db := NewStorageWithBackend("/tmp/.dim", NewMemoryBackend())
+
+	const workers = 16
+	const rounds = 20
+
+	users := make([]*UserInfo, 0, 4)
+	visaKeys := make(map[string][]PrivateKey, 4)
+	for i := 0; i < 4; i++ {
+		info := GenerateUserInfo("user", nil)
+		if !db.SaveMeta(info.Meta, info.ID) {
+			t.Fatalf("failed to save meta: %s", info.ID)
+		}
+		if !db.SavePrivateKey(info.IdentityKey.(PrivateKey), META_KEY, info.ID) {
+			t.Fatalf("failed to save identity key: %s", info.ID)
+		}
+		keys := []PrivateKey{info.CommunicationKey.(PrivateKey)}
+		for j := 0; j < 2; j++ {
+			keys = append(keys, GeneratePrivateKey(RSA))
+		}
+		visaKeys[info.ID.String()] = keys
+		users = append(users, info)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				info := users[(w+r)%len(users)]
+				uid := info.ID
+				// documents
+				db.SaveDocument(info.Visa, uid)
+				if docs := db.GetDocuments(uid); len(docs) == 0 {
+					t.Errorf("documents not found: %s", uid)
+				}
+				// private keys
+				keys := visaKeys[uid.String()]
+				db.SavePrivateKey(keys[(w+r)%len(keys)], VISA_KEY, uid)
+				if len(db.GetPrivateKeysForDecryption(uid)) == 0 {
+					t.Errorf("decryption keys not found: %s", uid)
+				}
+				if db.GetPrivateKeyForSignature(uid) == nil {
+					t.Errorf("sign key not found: %s", uid)
+				}
+				// contact infos, modify a copy like the ContactManager does
+				infos := db.GetContactInfos(uid)
+				array := make([]*ContactInfo, 0, len(infos)+1)
+				for _, item := range infos {
+					clone := *item
+					clone.Tags = append([]string{}, item.Tags...)
+					array = append(array, &clone)
+				}
+				friend := users[w%len(users)].ID
+				array = append(array, &ContactInfo{ID: friend, Alias: "friend"})
+				db.SaveContactInfos(array, uid)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, info := range users {
+		uid := info.ID
+		if docs := db.GetDocuments(uid); len(docs) != 1 {
+			t.Errorf("documents error: %s, %d", uid, len(docs))
+		}
+		if db.GetPrivateKeyForVisaSignature(uid) == nil {
+			t.Errorf("identity key not found: %s", uid)
+		}
+		// reload from backend
+		clone := NewStorageWithBackend(db.Root(), db.Backend())
+		if docs := clone.GetDocuments(uid); len(docs) != 1 {
+			t.Errorf("documents not saved: %s, %d", uid, len(docs))
+		}
+		if len(clone.GetPrivateKeysForDecryption(uid)) == 0 {
+			t.Errorf("decryption keys not saved: %s", uid)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isCJK checks whether the character is written without spaces between words
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// tokenize splits text into index terms:
+//
+//	words of letters & digits are lowercased as a whole,
+//	CJK runs are split into overlapping character bigrams
+//	(a single CJK character stays as a unigram)
+func tokenize(text string) []string {
+	tokens := make([]string, 0, 16)
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		if isCJK(r) {
+			flushWord()
+			cjk = append(cjk, r)
+		} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			flushCJK()
+			word = append(word, r)
+		} else {
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return uniqueTokens(tokens)
+}
+
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	array := make([]string, 0, len(tokens))
+	for _, item := range tokens {
+		if !seen[item] {
+			seen[item] = true
+			array = append(array, item)
+		}
+	}
+	return array
+}
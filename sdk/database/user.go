@@ -1,36 +1,108 @@
 package db
 
-import . "github.com/dimchat/mkm-go/protocol"
+import (
+	"strings"
+
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
 
 //-------- UserTable
 
 // Override
 func (db *Storage) GetLocalUsers() []ID {
-	return db.users
+	db.userLock.Lock()
+	defer db.userLock.Unlock()
+	return db.getLocalUsers()
 }
 
 // Override
 func (db *Storage) SaveLocalUsers(users []ID) bool {
-	db.users = users
-	return true
+	db.userLock.Lock()
+	defer db.userLock.Unlock()
+	return db.saveLocalUsers(users)
 }
 
+// AddUser appends a user to the local users,
+// the current user will not be changed
 func (db *Storage) AddUser(user ID) bool {
-	for _, id := range db.users {
+	db.userLock.Lock()
+	defer db.userLock.Unlock()
+	return db.addUser(user)
+}
+
+func (db *Storage) RemoveUser(user ID) bool {
+	db.userLock.Lock()
+	defer db.userLock.Unlock()
+	return db.removeUser(user)
+}
+
+// SetCurrentUser moves the user to the front of local users
+//
+// Returns: false if the user's private key not found
+func (db *Storage) SetCurrentUser(user ID) bool {
+	if db.GetPrivateKeyForSignature(user) == nil {
+		db.error("private key not found, cannot set current user: " + user.String())
+		return false
+	}
+	db.userLock.Lock()
+	defer db.userLock.Unlock()
+	local := db.getLocalUsers()
+	users := make([]ID, 0, len(local)+1)
+	users = append(users, user)
+	for _, id := range local {
+		if !user.Equal(id) {
+			users = append(users, id)
+		}
+	}
+	return db.saveLocalUsers(users)
+}
+
+func (db *Storage) GetCurrentUser() ID {
+	db.userLock.Lock()
+	defer db.userLock.Unlock()
+	users := db.getLocalUsers()
+	if len(users) == 0 {
+		return nil
+	}
+	return users[0]
+}
+
+// private
+func (db *Storage) getLocalUsers() []ID {
+	users := db.users
+	if users == nil {
+		users = loadUsers(db)
+		db.users = users
+	}
+	return users
+}
+
+// private
+func (db *Storage) saveLocalUsers(users []ID) bool {
+	db.users = users
+	return saveUsers(db, users)
+}
+
+// private
+func (db *Storage) addUser(user ID) bool {
+	local := db.getLocalUsers()
+	for _, id := range local {
 		if user.Equal(id) {
 			return false
 		}
 	}
-	users := make([]ID, 0, len(db.users)+1)
+	users := make([]ID, 0, len(local)+1)
+	users = append(users, local...)
 	users = append(users, user)
-	users = append(users, db.users...)
-	db.users = users
-	return true
+	return db.saveLocalUsers(users)
 }
 
-func (db *Storage) RemoveUser(user ID) bool {
+// private
+func (db *Storage) removeUser(user ID) bool {
+	local := db.getLocalUsers()
 	var pos = -1
-	for index, id := range db.users {
+	for index, id := range local {
 		if user.Equal(id) {
 			pos = index
 			break
@@ -40,18 +112,46 @@ func (db *Storage) RemoveUser(user ID) bool {
 		// user ID not found
 		return false
 	}
-	db.users = append(db.users[:pos], db.users[pos+1:]...)
-	return true
+	users := make([]ID, 0, len(local)-1)
+	users = append(users, local[:pos]...)
+	users = append(users, local[pos+1:]...)
+	return db.saveLocalUsers(users)
 }
 
-func (db *Storage) SetCurrentUser(user ID) {
-	db.RemoveUser(user)
-	db.AddUser(user)
+/**
+ *  Local Users
+ *  ~~~~~~~~~~~
+ *  The first one is the current user
+ *
+ *  file path: '.dim/private/users.txt'
+ */
+
+func usersPath(db *Storage) string {
+	return PathJoin(db.Root(), "private", "users.txt")
 }
 
-func (db *Storage) GetCurrentUser() ID {
-	if len(db.users) == 0 {
-		return nil
+func loadUsers(db *Storage) []ID {
+	path := usersPath(db)
+	db.log("Loading local users: " + path)
+	text := db.readText(path)
+	lines := strings.Split(text, "\n")
+	users := make([]ID, 0, len(lines))
+	for _, rec := range lines {
+		id := ParseID(rec)
+		if id != nil {
+			users = append(users, id)
+		}
+	}
+	return users
+}
+
+func saveUsers(db *Storage, users []ID) bool {
+	text := ""
+	lines := IDRevert(users)
+	for _, rec := range lines {
+		text = text + rec + "\n"
 	}
-	return db.users[0]
+	path := usersPath(db)
+	db.log("Saving local users: " + path)
+	return db.writeText(path, text)
 }
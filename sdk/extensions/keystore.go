@@ -0,0 +1,242 @@
+package sdk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeystoreVersion is the version of the keystore format
+const KeystoreVersion = 1
+
+// default scrypt parameters (interactive logins)
+const (
+	ScryptN = 1 << 15
+	ScryptR = 8
+	ScryptP = 1
+)
+
+// max scrypt parameters accepted when opening,
+// so a crafted file can't take too much memory (128 * N * r bytes) or time
+const (
+	ScryptMaxN = 1 << 20
+	ScryptMaxR = 16
+	ScryptMaxP = 16
+)
+
+// max count of derived keys kept by a keystore,
+// every sealed data has its own salt, so the older ones will be dropped
+const KeystoreCacheSize = 16
+
+const (
+	keystoreKDF    = "scrypt"
+	keystoreCipher = "aes-256-gcm"
+	keystoreSalt   = 16
+)
+
+/**
+ *  Keystore
+ *  ~~~~~~~~
+ *
+ *  Encrypts secret data with a passphrase:
+ *      key = scrypt(passphrase, salt, N, r, p)
+ *      data = AES-256-GCM(key, nonce, plaintext)
+ *
+ *  format: {
+ *      "version" : 1,
+ *      "kdf"     : "scrypt",
+ *      "salt"    : "{BASE64}",
+ *      "N"       : 32768,
+ *      "r"       : 8,
+ *      "p"       : 1,
+ *      "cipher"  : "aes-256-gcm",
+ *      "nonce"   : "{BASE64}",
+ *      "data"    : "{BASE64}"
+ *  }
+ */
+type Keystore struct {
+	passphrase []byte
+
+	N int
+	R int
+	P int
+
+	// derived keys: salt -> key
+	keys  map[string][]byte
+	order []string // tags of derived keys, the oldest first
+	lock  sync.Mutex
+}
+
+func NewKeystore(passphrase string) *Keystore {
+	return &Keystore{
+		passphrase: UTF8Encode(passphrase),
+		N:          ScryptN,
+		R:          ScryptR,
+		P:          ScryptP,
+		keys:       make(map[string][]byte, 4),
+		order:      make([]string, 0, 4),
+	}
+}
+
+// IsKeystoreData checks whether the data is in keystore format
+func IsKeystoreData(data []byte) bool {
+	info := decodeKeystore(data)
+	return info != nil
+}
+
+// GetKeystoreParams returns the scrypt parameters of the keystore data,
+// zeros if not in keystore format
+func GetKeystoreParams(data []byte) (n, r, p int) {
+	info := decodeKeystore(data)
+	if info == nil {
+		return 0, 0, 0
+	}
+	return ConvertInt(info["N"], 0), ConvertInt(info["r"], 0), ConvertInt(info["p"], 0)
+}
+
+// Seal encrypts the plaintext with a random salt & nonce
+//
+// Returns: keystore data, nil on error
+func (ks *Keystore) Seal(plaintext []byte) []byte {
+	salt := randomBytes(keystoreSalt)
+	if salt == nil {
+		return nil
+	}
+	aead := ks.cipher(salt, ks.N, ks.R, ks.P)
+	if aead == nil {
+		return nil
+	}
+	nonce := randomBytes(aead.NonceSize())
+	if nonce == nil {
+		return nil
+	}
+	aad := keystoreAAD(KeystoreVersion, ks.N, ks.R, ks.P)
+	ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+	info := NewMap()
+	info["version"] = KeystoreVersion
+	info["kdf"] = keystoreKDF
+	info["salt"] = Base64Encode(salt)
+	info["N"] = ks.N
+	info["r"] = ks.R
+	info["p"] = ks.P
+	info["cipher"] = keystoreCipher
+	info["nonce"] = Base64Encode(nonce)
+	info["data"] = Base64Encode(ciphertext)
+	return UTF8Encode(JSONEncodeMap(info))
+}
+
+// Open decrypts the keystore data
+//
+// Returns: plaintext, nil on wrong passphrase or broken data
+func (ks *Keystore) Open(data []byte) []byte {
+	info := decodeKeystore(data)
+	if info == nil {
+		return nil
+	}
+	version := ConvertInt(info["version"], 0)
+	if version != KeystoreVersion {
+		// unsupported version
+		return nil
+	}
+	n := ConvertInt(info["N"], 0)
+	r := ConvertInt(info["r"], 0)
+	p := ConvertInt(info["p"], 0)
+	if !checkScryptParams(n, r, p) {
+		return nil
+	}
+	salt := Base64Decode(ConvertString(info["salt"], ""))
+	nonce := Base64Decode(ConvertString(info["nonce"], ""))
+	ciphertext := Base64Decode(ConvertString(info["data"], ""))
+	if len(salt) == 0 || len(nonce) == 0 || len(ciphertext) == 0 {
+		return nil
+	}
+	aead := ks.cipher(salt, n, r, p)
+	if aead == nil || len(nonce) != aead.NonceSize() {
+		return nil
+	}
+	aad := keystoreAAD(version, n, r, p)
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil
+	}
+	return plaintext
+}
+
+// private
+func (ks *Keystore) cipher(salt []byte, n, r, p int) cipher.AEAD {
+	key := ks.deriveKey(salt, n, r, p)
+	if key == nil {
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil
+	}
+	return aead
+}
+
+// private
+func (ks *Keystore) deriveKey(salt []byte, n, r, p int) []byte {
+	tag := fmt.Sprintf("%s:%d:%d:%d", Base64Encode(salt), n, r, p)
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	key := ks.keys[tag]
+	if key == nil {
+		var err error
+		key, err = scrypt.Key(ks.passphrase, salt, n, r, p, 32)
+		if err != nil {
+			return nil
+		}
+		// drop the oldest one
+		if len(ks.order) >= KeystoreCacheSize {
+			delete(ks.keys, ks.order[0])
+			ks.order = ks.order[1:]
+		}
+		ks.keys[tag] = key
+		ks.order = append(ks.order, tag)
+	}
+	return key
+}
+
+// checkScryptParams checks N (power of 2), r & p in bounds
+func checkScryptParams(n, r, p int) bool {
+	if n <= 1 || n > ScryptMaxN || n&(n-1) != 0 {
+		return false
+	}
+	return r > 0 && r <= ScryptMaxR && p > 0 && p <= ScryptMaxP
+}
+
+func decodeKeystore(data []byte) StringKeyMap {
+	if len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+	info := JSONDecodeMap(UTF8Decode(data))
+	if info == nil || info["kdf"] != keystoreKDF || info["cipher"] != keystoreCipher {
+		return nil
+	}
+	return info
+}
+
+// keystoreAAD binds the header fields to the ciphertext
+func keystoreAAD(version, n, r, p int) []byte {
+	header := fmt.Sprintf("%d:%s:%d:%d:%d:%s", version, keystoreKDF, n, r, p, keystoreCipher)
+	return UTF8Encode(header)
+}
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,255 @@
+package sdk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+	"strconv"
+	"strings"
+
+	. "github.com/dimchat/core-go/protocol"
+	. "github.com/dimchat/mkm-go/crypto"
+	. "github.com/dimchat/mkm-go/format"
+	. "github.com/dimchat/mkm-go/types"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// MnemonicStrength is the default entropy bits for a new mnemonic (12 words)
+const MnemonicStrength = 128
+
+// IdentityKeyPath is the derivation path for identity key (ETH account #0)
+const IdentityKeyPath = "m/44'/60'/0'/0/0"
+
+const hardenedOffset = 0x80000000
+
+// order of the secp256k1 curve
+var curveOrder, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+/**
+ *  Mnemonic Phrase
+ *  ~~~~~~~~~~~~~~~
+ *
+ *  BIP-39: entropy + checksum, split into 11-bit indexes of the English wordlist
+ *      seed = PBKDF2-HMAC-SHA512(phrase, "mnemonic" + passphrase, 2048)
+ *
+ *  BIP-32: the identity key is derived from the seed along 'm/44'/60'/0'/0/0',
+ *          so the same phrase always regenerates the same meta & ID (ETH address)
+ */
+
+// GenerateMnemonic creates a random phrase with entropy bits: 128, 160, 192, 224 or 256
+//
+// Returns: words separated by spaces, empty string if strength invalid
+func GenerateMnemonic(strength int) string {
+	if strength < 128 || strength > 256 || strength%32 != 0 {
+		return ""
+	}
+	entropy := randomBytes(strength / 8)
+	if entropy == nil {
+		return ""
+	}
+	return entropyToMnemonic(entropy)
+}
+
+// CheckMnemonic checks the words and the checksum of the phrase
+func CheckMnemonic(mnemonic string) bool {
+	return mnemonicToEntropy(mnemonic) != nil
+}
+
+// MnemonicToSeed derives the 64-byte seed from phrase and optional passphrase
+func MnemonicToSeed(mnemonic string, passphrase string) []byte {
+	phrase := normalizeMnemonic(mnemonic)
+	salt := "mnemonic" + passphrase
+	return pbkdf2.Key(UTF8Encode(phrase), UTF8Encode(salt), 2048, 64, sha512.New)
+}
+
+// DeriveIdentityKey derives the ECC private key for meta from the phrase
+//
+// Returns: nil if the phrase is invalid
+func DeriveIdentityKey(mnemonic string, passphrase string) PrivateKey {
+	if !CheckMnemonic(mnemonic) {
+		return nil
+	}
+	seed := MnemonicToSeed(mnemonic, passphrase)
+	key := deriveKey(seed, IdentityKeyPath)
+	if key == nil {
+		return nil
+	}
+	return eccPrivateKey(key)
+}
+
+func normalizeMnemonic(mnemonic string) string {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	return strings.Join(words, " ")
+}
+
+func wordIndex(word string) int {
+	// the wordlist is sorted
+	low, high := 0, len(mnemonicWords)-1
+	for low <= high {
+		mid := (low + high) / 2
+		if mnemonicWords[mid] == word {
+			return mid
+		} else if mnemonicWords[mid] < word {
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return -1
+}
+
+func entropyToMnemonic(entropy []byte) string {
+	hash := sha256.Sum256(entropy)
+	// entropy bits + checksum bits (1 bit for every 32 bits)
+	bits := len(entropy) * 8
+	total := bits + bits/32
+	data := append(cloneBytes(entropy), hash[0])
+	words := make([]string, 0, total/11)
+	for pos := 0; pos < total; pos += 11 {
+		index := 0
+		for i := pos; i < pos+11; i++ {
+			index = index<<1 | int(data[i/8]>>(7-uint(i%8))&1)
+		}
+		words = append(words, mnemonicWords[index])
+	}
+	return strings.Join(words, " ")
+}
+
+func mnemonicToEntropy(mnemonic string) []byte {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	count := len(words)
+	if count < 12 || count > 24 || count%3 != 0 {
+		return nil
+	}
+	total := count * 11
+	checksum := total / 33
+	data := make([]byte, (total+7)/8)
+	for pos, word := range words {
+		index := wordIndex(word)
+		if index < 0 {
+			return nil
+		}
+		for i := 0; i < 11; i++ {
+			if index>>(10-uint(i))&1 == 1 {
+				bit := pos*11 + i
+				data[bit/8] |= 1 << (7 - uint(bit%8))
+			}
+		}
+	}
+	entropy := data[:(total-checksum)/8]
+	hash := sha256.Sum256(entropy)
+	mask := byte(0xFF << (8 - uint(checksum)))
+	if data[len(entropy)]&mask != hash[0]&mask {
+		return nil
+	}
+	return cloneBytes(entropy)
+}
+
+//
+//  HD Key (BIP-32)
+//
+
+func deriveKey(seed []byte, path string) []byte {
+	key, chain := hmacSHA512([]byte("Bitcoin seed"), seed)
+	if !validScalar(key) {
+		return nil
+	}
+	steps := strings.Split(path, "/")
+	if len(steps) == 0 || steps[0] != "m" {
+		return nil
+	}
+	for _, item := range steps[1:] {
+		var index uint32
+		if strings.HasSuffix(item, "'") {
+			index = hardenedOffset
+			item = item[:len(item)-1]
+		}
+		num, err := strconv.ParseUint(item, 10, 31)
+		if err != nil {
+			return nil
+		}
+		index += uint32(num)
+		key, chain = deriveChild(key, chain, index)
+		if key == nil {
+			return nil
+		}
+	}
+	return key
+}
+
+func deriveChild(key, chain []byte, index uint32) ([]byte, []byte) {
+	var data []byte
+	if index >= hardenedOffset {
+		// 0x00 || ser256(k) || ser32(i)
+		data = append([]byte{0}, key...)
+	} else {
+		// serP(point(k)) || ser32(i)
+		data = compressedPublicKey(key)
+		if data == nil {
+			return nil, nil
+		}
+	}
+	var ser [4]byte
+	binary.BigEndian.PutUint32(ser[:], index)
+	data = append(data, ser[:]...)
+	il, ir := hmacSHA512(chain, data)
+	if !validScalar(il) {
+		return nil, nil
+	}
+	// k_i = (IL + k) mod n
+	num := new(big.Int).SetBytes(il)
+	num.Add(num, new(big.Int).SetBytes(key))
+	num.Mod(num, curveOrder)
+	if num.Sign() == 0 {
+		return nil, nil
+	}
+	child := make([]byte, 32)
+	num.FillBytes(child)
+	return child, ir
+}
+
+func hmacSHA512(key, data []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+func validScalar(key []byte) bool {
+	num := new(big.Int).SetBytes(key)
+	return num.Sign() > 0 && num.Cmp(curveOrder) < 0
+}
+
+func eccPrivateKey(key []byte) PrivateKey {
+	info := NewMap()
+	info["algorithm"] = ECC
+	info["data"] = HexEncode(key)
+	info["curve"] = "SECP256k1"
+	info["digest"] = "SHA256"
+	return ParsePrivateKey(info)
+}
+
+func compressedPublicKey(key []byte) []byte {
+	privateKey := eccPrivateKey(key)
+	if privateKey == nil {
+		return nil
+	}
+	// 0x04 || X || Y
+	pub := privateKey.PublicKey().Data().Bytes()
+	if len(pub) != 65 {
+		return nil
+	}
+	prefix := byte(0x02)
+	if pub[64]&1 == 1 {
+		prefix = 0x03
+	}
+	return append([]byte{prefix}, pub[1:33]...)
+}
+
+func cloneBytes(data []byte) []byte {
+	clone := make([]byte, len(data))
+	copy(clone, data)
+	return clone
+}
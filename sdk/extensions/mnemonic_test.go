@@ -0,0 +1,39 @@
+package sdk
+
+import (
+	"testing"
+
+	. "github.com/dimpart/demo-go/sdk/common/ext"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// BIP-39 test vector, address of 'm/44'/60'/0'/0/0' without passphrase
+func TestMnemonicIdentity(t *testing.T) {
+	CommonExtensionLoader{}.Load()
+	CommonPluginLoader{}.Load()
+	LogLevel = 0
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon" +
+		" abandon abandon abandon abandon abandon about"
+	if !CheckMnemonic(mnemonic) {
+		t.Fatalf("mnemonic not valid: %s", mnemonic)
+	}
+	info := GenerateUserInfoWithMnemonic(mnemonic, "", "moky", nil)
+	if info == nil {
+		t.Fatalf("failed to generate user info: %s", mnemonic)
+	}
+	address := info.ID.Address().String()
+	if address != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("address not match: %s", address)
+	}
+	// recover
+	again := GenerateUserInfoWithMnemonic(mnemonic, "", "", nil)
+	if again == nil || !again.ID.Equal(info.ID) || !again.Meta.PublicKey().Equal(info.Meta.PublicKey()) {
+		t.Errorf("failed to recover: %s", info.ID)
+	}
+	// checksum
+	if CheckMnemonic("abandon abandon abandon abandon abandon abandon" +
+		" abandon abandon abandon abandon abandon abandon") {
+		t.Errorf("checksum not checked")
+	}
+}
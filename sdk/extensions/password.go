@@ -14,6 +14,9 @@ var BlockSize = 16
 
 /**
  *  This is for generating symmetric key with a text string
+ *
+ *  Deprecated: unsalted, use 'Keystore' for private keys;
+ *              kept for reading files saved by old versions
  */
 func GeneratePassword(password string) SymmetricKey {
 	data := UTF8Encode(password)
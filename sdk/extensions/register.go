@@ -38,6 +38,33 @@ func GenerateUserInfo(nickname string, avatar TransportableFile) *UserInfo {
 	//  Step 1. generate private key (with asymmetric algorithm)
 	//
 	idKey := GeneratePrivateKey(ECC)
+	return createUserInfo(idKey, nickname, avatar)
+}
+
+/**
+ *  Generate (or recover) user account info from mnemonic phrase
+ *
+ *  The identity key is derived from the phrase, so the same phrase
+ *  always gets the same ID & meta; the visa key is newly generated.
+ *
+ * @param mnemonic   - mnemonic words
+ * @param passphrase - optional passphrase for the phrase
+ * @param nickname   - nickname
+ * @param avatar     - photo URL
+ * @return nil if the phrase is invalid
+ */
+func GenerateUserInfoWithMnemonic(mnemonic string, passphrase string, nickname string, avatar TransportableFile) *UserInfo {
+	//
+	//  Step 1. derive private key from mnemonic
+	//
+	idKey := DeriveIdentityKey(mnemonic, passphrase)
+	if idKey == nil {
+		return nil
+	}
+	return createUserInfo(idKey, nickname, avatar)
+}
+
+func createUserInfo(idKey PrivateKey, nickname string, avatar TransportableFile) *UserInfo {
 	//
 	//  Step 2. generate meta with private key
 	//
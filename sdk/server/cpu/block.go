@@ -0,0 +1,61 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+)
+
+/**
+ *  CPU for BlockCommand
+ *  ~~~~~~~~~~~~~~~~~~~~
+ *  Save the block-list uploaded by the user,
+ *  or respond the stored block-list when queried
+ */
+
+type BlockCommandProcessor struct {
+	*BaseCommandProcessor
+}
+
+// private
+func (cpu *BlockCommandProcessor) getDatabase() BlockDBI {
+	facebook := cpu.BaseCommandProcessor.Facebook.(ICommonFacebook)
+	db, _ := facebook.GetDatabase().(BlockDBI)
+	return db
+}
+
+// Override
+func (cpu *BlockCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(BlockCommand)
+	if !ok {
+		return nil
+	}
+	db := cpu.getDatabase()
+	if db == nil {
+		return cpu.RespondReceipt("Block-list not supported.", rMsg.Envelope(), content, nil)
+	}
+	sender := rMsg.Sender()
+	blocked := command.BlockList()
+	if blocked == nil {
+		// query block-list
+		blocked = db.GetBlockList(sender)
+		return []Content{NewBlockCommand(blocked)}
+	} else if !db.SaveBlockList(blocked, sender) {
+		return cpu.RespondReceipt("Block-list not saved.", rMsg.Envelope(), content, StringKeyMap{
+			"template": "Block-list not saved: ${did}",
+			"replacements": StringKeyMap{
+				"did": sender.String(),
+			},
+		})
+	}
+	return cpu.RespondReceipt("Block-list received.", rMsg.Envelope(), content, StringKeyMap{
+		"template": "Block-list received: ${did}",
+		"replacements": StringKeyMap{
+			"did": sender.String(),
+		},
+	})
+}
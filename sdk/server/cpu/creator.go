@@ -0,0 +1,103 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimchat/sdk-go/dkd"
+	. "github.com/dimchat/sdk-go/sdk"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/server"
+)
+
+/**
+ *  CPU Creator
+ *  ~~~~~~~~~~~
+ *  Delegate for CPU factory on the station
+ */
+
+type ServerContentProcessorCreator struct {
+	*BaseContentProcessorCreator
+
+	Server   *SessionServer
+	Presence *PresenceCenter
+
+	// session of the connection which the messages come in on
+	Session Session
+}
+
+func NewServerContentProcessorCreator(facebook Facebook, messenger Messenger, server *SessionServer, presence *PresenceCenter, session Session) *ServerContentProcessorCreator {
+	return &ServerContentProcessorCreator{
+		BaseContentProcessorCreator: NewBaseContentProcessorCreator(facebook, messenger),
+		Server:                      server,
+		Presence:                    presence,
+		Session:                     session,
+	}
+}
+
+//-------- IProcessorCreator
+
+func (creator *ServerContentProcessorCreator) CreateCommandProcessor(msgType MessageType, cmdName string) ContentProcessor {
+	switch cmdName {
+	case LOGIN:
+		return NewLoginCommandProcessor(creator.Facebook, creator.Messenger, creator.Server, creator.Session)
+	case MUTE:
+		return NewMuteCommandProcessor(creator.Facebook, creator.Messenger)
+	case BLOCK:
+		return NewBlockCommandProcessor(creator.Facebook, creator.Messenger)
+	case REPORT, ONLINE, OFFLINE:
+		return NewReportCommandProcessor(creator.Facebook, creator.Messenger, creator.Server, creator.Session)
+	case PRESENCE:
+		return NewPresenceCommandProcessor(creator.Facebook, creator.Messenger, creator.Server, creator.Presence)
+	case SUBSCRIBE:
+		return NewSubscribeCommandProcessor(creator.Facebook, creator.Messenger, creator.Presence)
+	}
+	// others
+	return creator.BaseContentProcessorCreator.CreateCommandProcessor(msgType, cmdName)
+}
+
+//
+//  Factories
+//
+
+func NewLoginCommandProcessor(facebook Facebook, messenger Messenger, server *SessionServer, session Session) ContentProcessor {
+	return &LoginCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+		Server:               server,
+		Session:              session,
+	}
+}
+
+func NewMuteCommandProcessor(facebook Facebook, messenger Messenger) ContentProcessor {
+	return &MuteCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+	}
+}
+
+func NewBlockCommandProcessor(facebook Facebook, messenger Messenger) ContentProcessor {
+	return &BlockCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+	}
+}
+
+func NewReportCommandProcessor(facebook Facebook, messenger Messenger, server *SessionServer, session Session) ContentProcessor {
+	return &ReportCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+		Server:               server,
+		Session:              session,
+	}
+}
+
+func NewPresenceCommandProcessor(facebook Facebook, messenger Messenger, server *SessionServer, presence *PresenceCenter) ContentProcessor {
+	return &PresenceCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+		Server:               server,
+		Presence:             presence,
+	}
+}
+
+func NewSubscribeCommandProcessor(facebook Facebook, messenger Messenger, presence *PresenceCenter) ContentProcessor {
+	return &SubscribeCommandProcessor{
+		BaseCommandProcessor: NewBaseCommandProcessor(facebook, messenger),
+		Presence:             presence,
+	}
+}
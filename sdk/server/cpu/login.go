@@ -1,24 +1,48 @@
 package cpu
 
 import (
-	. "github.com/dimchat/core-go/dkd"
-	. "github.com/dimchat/core-go/protocol"
 	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
 	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/server"
 	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
+/**
+ *  CPU for LoginCommand
+ *  ~~~~~~~~~~~~~~~~~~~~
+ *  Bind the session with the sender and mark it active,
+ *  then post notification "user_online"
+ */
+
 type LoginCommandProcessor struct {
 	*BaseCommandProcessor
+
+	Server *SessionServer
+	// session which the login command comes in on
+	Session Session
 }
 
-func (cpu *LoginCommandProcessor) Execute(cmd Command, rMsg ReliableMessage) Content {
+// Override
+func (cpu *LoginCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(LoginCommand)
+	if !ok {
+		return nil
+	}
 	sender := rMsg.Sender()
+	session := cpu.Session
+	if session == nil {
+		return cpu.RespondReceipt("Login command error.", rMsg.Envelope(), content, nil)
+	}
+	if old := session.ID(); old == nil || !old.Equal(sender) {
+		cpu.Server.UpdateSession(session, sender)
+	}
+	session.SetActive(true)
+	// post notification: USER_ONLINE (after the session activated)
 	info := NewMap()
 	info["ID"] = sender.String()
-	info["cmd"] = cmd.Map()
-	// post notification: USER_ONLINE
+	info["cmd"] = command.Map()
 	NotificationPost("user_online", cpu, info)
-	return NewReceiptCommand("Login received", rMsg.Envelope(), cmd)
+	return cpu.RespondReceipt("Login received.", rMsg.Envelope(), content, nil)
 }
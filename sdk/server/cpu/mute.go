@@ -0,0 +1,61 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+)
+
+/**
+ *  CPU for MuteCommand
+ *  ~~~~~~~~~~~~~~~~~~~
+ *  Save the mute-list uploaded by the user,
+ *  or respond the stored mute-list when queried
+ */
+
+type MuteCommandProcessor struct {
+	*BaseCommandProcessor
+}
+
+// private
+func (cpu *MuteCommandProcessor) getDatabase() MuteDBI {
+	facebook := cpu.BaseCommandProcessor.Facebook.(ICommonFacebook)
+	db, _ := facebook.GetDatabase().(MuteDBI)
+	return db
+}
+
+// Override
+func (cpu *MuteCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(MuteCommand)
+	if !ok {
+		return nil
+	}
+	db := cpu.getDatabase()
+	if db == nil {
+		return cpu.RespondReceipt("Mute-list not supported.", rMsg.Envelope(), content, nil)
+	}
+	sender := rMsg.Sender()
+	muted := command.MuteList()
+	if muted == nil {
+		// query mute-list
+		muted = db.GetMuteList(sender)
+		return []Content{NewMuteCommand(muted)}
+	} else if !db.SaveMuteList(muted, sender) {
+		return cpu.RespondReceipt("Mute-list not saved.", rMsg.Envelope(), content, StringKeyMap{
+			"template": "Mute-list not saved: ${did}",
+			"replacements": StringKeyMap{
+				"did": sender.String(),
+			},
+		})
+	}
+	return cpu.RespondReceipt("Mute-list received.", rMsg.Envelope(), content, StringKeyMap{
+		"template": "Mute-list received: ${did}",
+		"replacements": StringKeyMap{
+			"did": sender.String(),
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/server"
+)
+
+/**
+ *  CPU for PresenceCommand
+ *  ~~~~~~~~~~~~~~~~~~~~~~~
+ *  Respond the online status & last seen time of a contact
+ */
+
+type PresenceCommandProcessor struct {
+	*BaseCommandProcessor
+
+	Server   *SessionServer
+	Presence *PresenceCenter
+}
+
+// private
+func (cpu *PresenceCommandProcessor) getDatabase() BlockDBI {
+	// NOTICE: 'sdk/common' can't be imported here for the 'Session' conflict
+	facebook, ok := cpu.BaseCommandProcessor.Facebook.(interface{ GetDatabase() AccountDBI })
+	if !ok {
+		return nil
+	}
+	db, _ := facebook.GetDatabase().(BlockDBI)
+	return db
+}
+
+// private
+func (cpu *PresenceCommandProcessor) isAllowed(sender, target ID) bool {
+	// 1. check block-list of the target
+	if db := cpu.getDatabase(); db != nil {
+		for _, item := range db.GetBlockList(target) {
+			if item.Equal(sender) {
+				return false
+			}
+		}
+	}
+	// 2. check presence policy
+	center := cpu.Presence
+	return center == nil || center.IsAllowed(sender, target)
+}
+
+// Override
+func (cpu *PresenceCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(PresenceCommand)
+	if !ok {
+		return nil
+	}
+	did := command.ID()
+	if did == nil || !did.IsUser() {
+		return cpu.RespondReceipt("Presence command error.", rMsg.Envelope(), content, nil)
+	}
+	if !cpu.isAllowed(rMsg.Sender(), did) {
+		// hide the presence, don't let the sender know it's blocked
+		return []Content{NewPresenceResponse(did, false, nil)}
+	}
+	server := cpu.Server
+	online := server.IsActive(did)
+	lastSeen := server.GetLastSeen(did)
+	res := NewPresenceResponse(did, online, lastSeen)
+	return []Content{res}
+}
@@ -0,0 +1,81 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/types"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/server"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+/**
+ *  CPU for ReportCommand
+ *  ~~~~~~~~~~~~~~~~~~~~~
+ *  Mark the session active/inactive when reported online/offline,
+ *  the other devices of the sender won't be changed
+ */
+
+type ReportCommandProcessor struct {
+	*BaseCommandProcessor
+
+	Server *SessionServer
+	// session which the report comes in on
+	Session Session
+}
+
+// Override
+func (cpu *ReportCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(ReportCommand)
+	if !ok {
+		return nil
+	}
+	title := command.Title()
+	if title == "" || title == REPORT {
+		// command name as title: "online" / "offline"
+		title = command.CMD()
+	}
+	var active bool
+	var name string
+	switch title {
+	case ONLINE:
+		active = true
+		name = "user_online"
+	case OFFLINE:
+		active = false
+		name = "user_offline"
+	default:
+		return cpu.RespondReceipt("Report command error.", rMsg.Envelope(), content, StringKeyMap{
+			"template": "Report title not supported: ${title}",
+			"replacements": StringKeyMap{
+				"title": title,
+			},
+		})
+	}
+	sender := rMsg.Sender()
+	session := cpu.Session
+	if session == nil || !sender.Equal(session.ID()) {
+		return cpu.RespondReceipt("Report command error.", rMsg.Envelope(), content, StringKeyMap{
+			"template": "Session not login: ${did}",
+			"replacements": StringKeyMap{
+				"did": sender.String(),
+			},
+		})
+	}
+	session.SetActive(active)
+	server := cpu.Server
+	// record last seen time
+	when := command.Time()
+	if TimeIsNil(when) || TimeIsAfter(TimeNow(), when) {
+		// calibrate the clock
+		when = TimeNow()
+	}
+	server.SetLastSeen(sender, when)
+	// post notification: USER_ONLINE / USER_OFFLINE
+	info := NewMap()
+	info["ID"] = sender.String()
+	info["cmd"] = command.Map()
+	NotificationPost(name, cpu, info)
+	// no need to respond report command
+	return nil
+}
@@ -0,0 +1,48 @@
+package cpu
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/sdk-go/cpu"
+	. "github.com/dimpart/demo-go/sdk/common/protocol"
+	. "github.com/dimpart/demo-go/sdk/server"
+)
+
+/**
+ *  CPU for SubscribeCommand
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Update the presence subscriptions of the sender (only the allowed users),
+ *  and respond the current presence of them
+ */
+
+type SubscribeCommandProcessor struct {
+	*BaseCommandProcessor
+
+	Presence *PresenceCenter
+}
+
+// Override
+func (cpu *SubscribeCommandProcessor) ProcessContent(content Content, rMsg ReliableMessage) []Content {
+	command, ok := content.(SubscribeCommand)
+	if !ok {
+		return nil
+	}
+	center := cpu.Presence
+	if center == nil {
+		return cpu.RespondReceipt("Presence subscription not supported.", rMsg.Envelope(), content, nil)
+	}
+	sender := rMsg.Sender()
+	targets := make([]ID, 0, len(command.SubscribeList()))
+	for _, item := range command.SubscribeList() {
+		if center.IsAllowed(sender, item) {
+			targets = append(targets, item)
+		}
+	}
+	center.Subscribe(sender, targets)
+	// respond current presence
+	responses := make([]Content, 0, len(targets))
+	for _, target := range targets {
+		responses = append(responses, center.GetPresence(target))
+	}
+	return responses
+}
@@ -0,0 +1,172 @@
+package sdk
+
+import (
+	"sync"
+
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/common/dkd"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// ContentSender sends contents from the station (e.g.: the common Transmitter)
+type ContentSender interface {
+	SendContent(content Content, sender, receiver ID, priority int) Pair[InstantMessage, ReliableMessage]
+}
+
+/**
+ *  Presence Center
+ *  ~~~~~~~~~~~~~~~
+ *  Keep the presence subscriptions,
+ *  push online/offline events to the subscribers
+ *
+ *  Observes notifications: "user_online", "user_offline", "user_disconnected"
+ */
+type PresenceCenter struct {
+	//NotificationObserver
+
+	Server *SessionServer
+
+	// station ID & transmitter for pushing events
+	Station     ID
+	Transmitter ContentSender
+
+	// only the user's contacts can subscribe,
+	// and the ones blocked by the user won't get the events
+	ContactDB ContactDBI
+	BlockDB   BlockDBI
+
+	// subscriber => targets
+	subscriptions map[string][]ID
+	// target => subscribers
+	subscribers map[string][]ID
+
+	lock sync.RWMutex
+}
+
+func NewPresenceCenter(server *SessionServer, station ID, transmitter ContentSender) *PresenceCenter {
+	center := &PresenceCenter{
+		Server:        server,
+		Station:       station,
+		Transmitter:   transmitter,
+		ContactDB:     nil,
+		BlockDB:       nil,
+		subscriptions: make(map[string][]ID, 1024),
+		subscribers:   make(map[string][]ID, 1024),
+	}
+	NotificationAddObserver(center, "user_online")
+	NotificationAddObserver(center, "user_offline")
+	NotificationAddObserver(center, "user_disconnected")
+	return center
+}
+
+// Subscribe replaces the subscriptions of the subscriber
+func (center *PresenceCenter) Subscribe(subscriber ID, targets []ID) {
+	center.lock.Lock()
+	defer center.lock.Unlock()
+	key := subscriber.String()
+	// 1. remove old subscriptions
+	for _, target := range center.subscriptions[key] {
+		center.subscribers[target.String()] = removeSubscriber(center.subscribers[target.String()], subscriber)
+	}
+	if len(targets) == 0 {
+		delete(center.subscriptions, key)
+		return
+	}
+	// 2. add new subscriptions
+	center.subscriptions[key] = targets
+	for _, target := range targets {
+		array := center.subscribers[target.String()]
+		center.subscribers[target.String()] = append(removeSubscriber(array, subscriber), subscriber)
+	}
+}
+
+// Unsubscribe removes all subscriptions of the subscriber
+func (center *PresenceCenter) Unsubscribe(subscriber ID) {
+	center.Subscribe(subscriber, nil)
+}
+
+// GetSubscribers returns the subscribers who watch the target
+func (center *PresenceCenter) GetSubscribers(target ID) []ID {
+	center.lock.RLock()
+	defer center.lock.RUnlock()
+	array := center.subscribers[target.String()]
+	subscribers := make([]ID, len(array))
+	copy(subscribers, array)
+	return subscribers
+}
+
+// IsAllowed checks whether the subscriber can see the presence of the target
+//
+// Returns: false if the subscriber is not a contact of the target, or blocked by the target
+func (center *PresenceCenter) IsAllowed(subscriber, target ID) bool {
+	if cdb := center.ContactDB; cdb == nil || !containsID(cdb.GetContacts(target), subscriber) {
+		// not a contact of the target
+		return false
+	} else if bdb := center.BlockDB; bdb != nil && containsID(bdb.GetBlockList(target), subscriber) {
+		// blocked by the target
+		return false
+	}
+	return true
+}
+
+// GetPresence creates the presence event of the target
+func (center *PresenceCenter) GetPresence(target ID) Content {
+	server := center.Server
+	online := server.IsActive(target)
+	lastSeen := server.GetLastSeen(target)
+	return NewPresenceEvent(target, online, lastSeen)
+}
+
+// Override
+func (center *PresenceCenter) OnNotificationReceived(notify Notification) {
+	info := notify.Info()
+	target := ParseID(info["ID"])
+	if target == nil {
+		return
+	} else if notify.Name() == "user_disconnected" {
+		// all sessions closed, stop pushing events to this user
+		center.Unsubscribe(target)
+	}
+	content := center.GetPresence(target)
+	for _, subscriber := range center.GetSubscribers(target) {
+		if !center.IsAllowed(subscriber, target) {
+			continue
+		}
+		center.push(content, subscriber)
+	}
+}
+
+// private
+func (center *PresenceCenter) push(content Content, subscriber ID) {
+	transmitter := center.Transmitter
+	if transmitter == nil {
+		//panic("transmitter not ready")
+		return
+	}
+	if !center.Server.IsActive(subscriber) {
+		// subscriber offline, no need to push
+		return
+	}
+	transmitter.SendContent(content, center.Station, subscriber, 1)
+}
+
+func containsID(array []ID, did ID) bool {
+	for _, item := range array {
+		if item.Equal(did) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeSubscriber(array []ID, subscriber ID) []ID {
+	result := make([]ID, 0, len(array))
+	for _, item := range array {
+		if !item.Equal(subscriber) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package sdk
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimchat/sdk-go/sdk"
+)
+
+/**
+ *  Server Message Processor
+ *  ~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Process messages sent to the station,
+ *  deliver the others to the receivers via the message router
+ */
+type ServerMessageProcessor struct {
+	*MessageProcessor
+
+	Router *MessageRouter
+}
+
+func NewServerMessageProcessor(facebook Facebook, messenger Messenger, router *MessageRouter) *ServerMessageProcessor {
+	return &ServerMessageProcessor{
+		MessageProcessor: NewMessageProcessor(facebook, messenger),
+		Router:           router,
+	}
+}
+
+// Override
+func (processor *ServerMessageProcessor) ProcessReliableMessage(rMsg ReliableMessage) []ReliableMessage {
+	receiver := rMsg.Receiver()
+	router := processor.Router
+	if router == nil || !isDeliverable(receiver) {
+		// message for the station
+		return processor.MessageProcessor.ProcessReliableMessage(rMsg)
+	}
+	// message for other user, deliver it
+	// (blocked senders refused, muted ones won't be pushed)
+	router.Deliver(rMsg)
+	return nil
+}
+
+// isDeliverable checks whether the receiver is a user other than the station
+func isDeliverable(receiver ID) bool {
+	return receiver.IsUser() && !receiver.IsBroadcast() && receiver.Type() != STATION
+}
@@ -0,0 +1,99 @@
+package sdk
+
+import (
+	. "github.com/dimchat/dkd-go/protocol"
+	. "github.com/dimchat/mkm-go/protocol"
+	. "github.com/dimpart/demo-go/sdk/common/db"
+	. "github.com/dimpart/demo-go/sdk/utils"
+)
+
+// PushService sends notifications to the offline devices
+type PushService interface {
+	PushNotification(sender, receiver ID, msg ReliableMessage) bool
+}
+
+/**
+ *  Message Router
+ *  ~~~~~~~~~~~~~~
+ *  Deliver messages to the receiver's active sessions,
+ *  refuse the blocked senders and skip notifications for the muted ones
+ *
+ *  Used by the ServerMessageProcessor for messages not sent to the station
+ */
+type MessageRouter struct {
+	Server *SessionServer
+
+	MuteDB  MuteDBI
+	BlockDB BlockDBI
+
+	Pusher PushService
+}
+
+func NewMessageRouter(server *SessionServer, muteDB MuteDBI, blockDB BlockDBI) *MessageRouter {
+	return &MessageRouter{
+		Server:  server,
+		MuteDB:  muteDB,
+		BlockDB: blockDB,
+		Pusher:  nil,
+	}
+}
+
+// Deliver pushes the message to the receiver
+//
+// Returns: false if refused or no active session
+func (router *MessageRouter) Deliver(msg ReliableMessage) bool {
+	sender := msg.Sender()
+	receiver := msg.Receiver()
+	if router.IsBlocked(sender, receiver, msg.Group()) {
+		LogInfo("Router > refuse message from blocked sender: " + sender.String() + " -> " + receiver.String())
+		return false
+	}
+	// push to active sessions
+	delivered := false
+	sessions := router.Server.ActiveSessions(receiver)
+	for _, session := range sessions {
+		if session.PushMessage(msg) {
+			delivered = true
+		}
+	}
+	if delivered {
+		return true
+	}
+	// receiver offline, notify the devices
+	pusher := router.Pusher
+	if pusher == nil || router.IsMuted(sender, receiver, msg.Group()) {
+		// no need to push notification
+		return false
+	}
+	pusher.PushNotification(sender, receiver, msg)
+	return false
+}
+
+// IsBlocked checks whether the sender (or group) is in the receiver's block-list
+func (router *MessageRouter) IsBlocked(sender, receiver, group ID) bool {
+	db := router.BlockDB
+	if db == nil {
+		return false
+	}
+	return listContains(db.GetBlockList(receiver), sender, group)
+}
+
+// IsMuted checks whether the sender (or group) is in the receiver's mute-list
+func (router *MessageRouter) IsMuted(sender, receiver, group ID) bool {
+	db := router.MuteDB
+	if db == nil {
+		return false
+	}
+	return listContains(db.GetMuteList(receiver), sender, group)
+}
+
+func listContains(array []ID, sender, group ID) bool {
+	for _, item := range array {
+		if item.Equal(sender) {
+			return true
+		} else if group != nil && item.Equal(group) {
+			return true
+		}
+	}
+	return false
+}
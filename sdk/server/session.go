@@ -1,11 +1,14 @@
 package sdk
 
 import (
+	"sync"
+
 	. "github.com/dimchat/dkd-go/protocol"
 	. "github.com/dimchat/mkm-go/format"
 	. "github.com/dimchat/mkm-go/protocol"
 	. "github.com/dimchat/mkm-go/types"
 	. "github.com/dimchat/plugins-go/types"
+	. "github.com/dimpart/demo-go/sdk/utils"
 )
 
 // format "(IP, Port)"
@@ -97,12 +100,17 @@ func (session *BaseSession) PushMessage(msg ReliableMessage) bool {
 type SessionServer struct {
 	clientAddresses map[string][]SessionAddress
 	sessions        map[SessionAddress]Session
+
+	// user ID -> last seen time
+	lastSeen     map[string]Time
+	lastSeenLock sync.RWMutex
 }
 
 func NewSessionServer() *SessionServer {
 	return &SessionServer{
 		clientAddresses: make(map[string][]SessionAddress, 1024),
 		sessions:        make(map[SessionAddress]Session, 1024),
+		lastSeen:        make(map[string]Time, 1024),
 	}
 }
 
@@ -178,6 +186,12 @@ func (server *SessionServer) RemoveSession(session Session) {
 	// 2. remove session with client_address
 	session.SetActive(false)
 	delete(server.sessions, address)
+	// 3. post notification: USER_DISCONNECTED when all sessions closed
+	if identifier != nil && len(server.AllSessions(identifier)) == 0 {
+		info := NewMap()
+		info["ID"] = identifier.String()
+		NotificationPost("user_disconnected", server, info)
+	}
 }
 
 // Get all sessions of this user
@@ -248,3 +262,19 @@ func (server *SessionServer) ActiveUsers() []ID {
 	}
 	return users
 }
+
+//
+//  Presence
+//
+
+func (server *SessionServer) GetLastSeen(identifier ID) Time {
+	server.lastSeenLock.RLock()
+	defer server.lastSeenLock.RUnlock()
+	return server.lastSeen[identifier.String()]
+}
+
+func (server *SessionServer) SetLastSeen(identifier ID, when Time) {
+	server.lastSeenLock.Lock()
+	defer server.lastSeenLock.Unlock()
+	server.lastSeen[identifier.String()] = when
+}
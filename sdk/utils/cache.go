@@ -0,0 +1,142 @@
+/* license: https://mit-license.org
+ * ==============================================================================
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2026 Albert Moky
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ * ==============================================================================
+ */
+package utils
+
+import (
+	"sync"
+
+	. "github.com/dimchat/plugins-go/mem"
+)
+
+// CacheStatistics holds the counters of a metered cache
+type CacheStatistics struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+
+	Size    int
+	MaxSize int // 0 means unlimited
+}
+
+// MeteredCache wraps a MemoryCache with thread safety, hit/miss/eviction counters
+// and an optional maximum size
+//
+// When the size exceeds the limit after a Put, the inner cache will be reduced
+type MeteredCache[K comparable, V any] struct {
+	//MemoryCache
+
+	cache   MemoryCache[K, V]
+	maxSize int
+
+	hits      int64
+	misses    int64
+	evictions int64
+
+	lock sync.Mutex
+}
+
+func NewMeteredCache[K comparable, V any](cache MemoryCache[K, V], maxSize int) *MeteredCache[K, V] {
+	return &MeteredCache[K, V]{
+		cache:   cache,
+		maxSize: maxSize,
+	}
+}
+
+// Override
+func (cache *MeteredCache[K, V]) Get(key K) V {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	value := cache.cache.Get(key)
+	if any(value) == nil {
+		cache.misses++
+	} else {
+		cache.hits++
+	}
+	return value
+}
+
+// Override
+func (cache *MeteredCache[K, V]) Put(key K, value V) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.cache.Put(key, value)
+	if cache.maxSize > 0 && cache.cache.Size() > cache.maxSize {
+		cache.reduce()
+	}
+}
+
+// Override
+func (cache *MeteredCache[K, V]) Size() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.cache.Size()
+}
+
+// Override
+func (cache *MeteredCache[K, V]) ReduceMemory() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.reduce()
+}
+
+// private
+func (cache *MeteredCache[K, V]) reduce() int {
+	before := cache.cache.Size()
+	after := cache.cache.ReduceMemory()
+	if before > after {
+		cache.evictions += int64(before - after)
+	}
+	return after
+}
+
+func (cache *MeteredCache[K, V]) SetMaxSize(maxSize int) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.maxSize = maxSize
+	if maxSize > 0 {
+		size := cache.cache.Size()
+		for size > maxSize {
+			after := cache.reduce()
+			if after >= size {
+				// cannot reduce any more
+				break
+			}
+			size = after
+		}
+	}
+}
+
+func (cache *MeteredCache[K, V]) GetStatistics() CacheStatistics {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return CacheStatistics{
+		Hits:      cache.hits,
+		Misses:    cache.misses,
+		Evictions: cache.evictions,
+		Size:      cache.cache.Size(),
+		MaxSize:   cache.maxSize,
+	}
+}
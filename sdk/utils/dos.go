@@ -26,8 +26,10 @@
 package utils
 
 import (
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 
 	. "github.com/dimchat/mkm-go/format"
 )
@@ -46,6 +48,9 @@ func PathJoin(elem ...string) string {
 func PathDir(filepath string) string {
 	return path.Dir(filepath)
 }
+func PathBase(filepath string) string {
+	return path.Base(filepath)
+}
 
 func PathIsExist(path string) bool {
 	_, err := os.Stat(path)
@@ -56,6 +61,34 @@ func PathRemove(path string) bool {
 	return err == nil
 }
 
+// PathRename moves the file 'src' to 'dst', replacing the old one
+func PathRename(src, dst string) bool {
+	err := os.Rename(src, dst)
+	return err == nil
+}
+
+// PathLink makes a hard link 'dst' to the file 'src', replacing the old one;
+// the link keeps the current content even if 'src' is replaced later
+func PathLink(src, dst string) bool {
+	if PathIsExist(dst) && !PathRemove(dst) {
+		return false
+	}
+	err := os.Link(src, dst)
+	return err == nil
+}
+
+// PathList returns all file paths under the directory (recursively)
+func PathList(dir string) []string {
+	files := make([]string, 0, 16)
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, filepath.ToSlash(path))
+		}
+		return nil
+	})
+	return files
+}
+
 //
 //  Binary File
 //
@@ -68,9 +101,43 @@ func ReadBinaryFile(path string) []byte {
 	return nil
 }
 func WriteBinaryFile(path string, data []byte) bool {
-	err := os.WriteFile(path, data, 0644)
+	err := writeFileAtomically(path, data, 0644)
 	return err == nil
 }
+
+// writeFileAtomically writes data to a temporary file in the same directory,
+// flushes it to disk, and then renames it to the target file,
+// so the target is either the old version or the new one, never a broken one
+func writeFileAtomically(filename string, data []byte, perm os.FileMode) error {
+	dir := path.Dir(filename)
+	fd, err := os.CreateTemp(dir, path.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := fd.Name()
+	if _, err = fd.Write(data); err == nil {
+		err = fd.Sync()
+	}
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	// flush the directory entry
+	if dirFd, err := os.Open(dir); err == nil {
+		_ = dirFd.Sync()
+		_ = dirFd.Close()
+	}
+	return nil
+}
 func AppendBinaryFile(path string, data []byte) bool {
 	fd, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 	if err == nil {
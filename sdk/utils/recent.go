@@ -73,6 +73,8 @@ func (checker *RecentTimeChecker[K]) IsExpired(key K, now Time) bool {
 		//panic("recent time empty")
 		return true
 	}
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
 	last := checker.times[key]
 	return last != nil && TimeIsAfter(now, last)
 }